@@ -184,6 +184,7 @@ func main() {
 			return
 		}
 		reader := vcard.NewDirectoryInfoReader(f)
+		reader.Logger = log.New(os.Stderr, "", log.LstdFlags)
 		addressBook.ReadFrom(reader)
 		log.Printf("Read %s\n", abpath)
 	}
@@ -0,0 +1,163 @@
+package vcard
+
+import (
+	"regexp"
+	"strings"
+)
+
+// a US or Canadian region followed by a postal code, e.g: IL 62704,
+// either may be missing
+var regionPostalCode = regexp.MustCompile(`^(?:([A-Za-z][A-Za-z .]*?)\s+)?(\d{5}(?:-\d{4})?|[A-Za-z]\d[A-Za-z] ?\d[A-Za-z]\d)$|^([A-Z]{2})$`)
+
+var postOfficeBoxPrefix = regexp.MustCompile(`(?i)^p\.?\s*o\.?\s*box\b`)
+
+// country names recognized without a region or postal code before them,
+// lowercased
+var countryNames = map[string]bool{
+	"us": true, "u.s.": true, "usa": true, "u.s.a.": true, "united states": true,
+	"united states of america": true, "canada": true,
+}
+
+// best effort parsing of a US style single line address, e.g:
+// "123 Main St, Apt 4, Springfield, IL 62704, USA"
+// missing components are left empty
+func ParseAddress(s string) Address {
+	var parts []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	var addr Address
+	end := len(parts)
+	// the country follows the region and postal code, or is a known name
+	if end >= 2 && countryNames[strings.ToLower(parts[end-1])] ||
+		end >= 3 && !regionPostalCode.MatchString(parts[end-1]) && regionPostalCode.MatchString(parts[end-2]) {
+		addr.CountryName = parts[end-1]
+		end--
+	}
+	region := false
+	if end >= 2 {
+		if m := regionPostalCode.FindStringSubmatch(parts[end-1]); m != nil {
+			addr.Region = m[1] + m[3]
+			addr.PostalCode = m[2]
+			end--
+			region = true
+		}
+	}
+	// the locality precedes the region, e.g: "Springfield, IL 62704"
+	if end >= 2 || end == 1 && region {
+		addr.Locality = parts[end-1]
+		end--
+	}
+	if end >= 1 {
+		if postOfficeBoxPrefix.MatchString(parts[0]) {
+			addr.PostOfficeBox = parts[0]
+		} else {
+			addr.Street = parts[0]
+		}
+		addr.ExtendedAddress = strings.Join(parts[1:end], ", ")
+	}
+	return addr
+}
+
+// return the address on a single line, the inverse of ParseAddress, e.g:
+// "123 Main St, Apt 4, Springfield, IL 62704, USA"
+func (addr *Address) Format() string {
+	var parts []string
+	for _, part := range []string{addr.PostOfficeBox, addr.Street, addr.ExtendedAddress, addr.Locality,
+		strings.TrimSpace(addr.Region + " " + addr.PostalCode), addr.CountryName} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// return true if the types of a are all in b, compared case-insensitively
+func typesIn(a, b TypeSet) bool {
+	for _, t := range a {
+		if !b.Has(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// set the 3.0 LABEL properties read as the label of the address whose types
+// are the closest superset of the label types, the first address winning
+// on equal distance. unmatched labels are kept in Extras
+func (vcard *VCard) matchDeliveryLabels() {
+	for _, contentLine := range vcard.deliveryLabels {
+		types := defaultAddressTypes()
+		if param, ok := contentLine.Param("TYPE"); ok {
+			types = TypeSet(param)
+		}
+		var best *Address
+		for i := range vcard.Addresses {
+			addr := &vcard.Addresses[i]
+			if addr.Label != "" || !typesIn(types, addr.Type) {
+				continue
+			}
+			if best == nil || len(addr.Type) < len(best.Type) {
+				best = addr
+			}
+		}
+		if best != nil {
+			best.Label = contentLine.Value.GetText()
+		} else {
+			vcard.Extras = append(vcard.Extras, contentLine)
+		}
+	}
+	vcard.deliveryLabels = nil
+}
+
+// values of X-MS-OL-DEFAULT-POSTAL-ADDRESS, the type of the Outlook mailing
+// address: 1 for home, 2 for work and 3 for other, 0 for none
+const (
+	outlookHomePostal  = "1"
+	outlookWorkPostal  = "2"
+	outlookOtherPostal = "3"
+)
+
+// return the X-MS-OL-DEFAULT-POSTAL-ADDRESS value of the address type
+func outlookPostal(addr *Address) string {
+	switch {
+	case addr.Type.Has("home"):
+		return outlookHomePostal
+	case addr.Type.Has("work"):
+		return outlookWorkPostal
+	}
+	return outlookOtherPostal
+}
+
+// set DefaultPostal on the first address whose type is the one of the
+// X-MS-OL-DEFAULT-POSTAL-ADDRESS read. an unmatched value is kept in Extras
+func (vcard *VCard) matchDefaultPostal() {
+	contentLine := vcard.defaultPostal
+	if contentLine == nil {
+		return
+	}
+	vcard.defaultPostal = nil
+	value := strings.TrimSpace(contentLine.Value.GetText())
+	if value == "0" {
+		return
+	}
+	for i := range vcard.Addresses {
+		if outlookPostal(&vcard.Addresses[i]) == value {
+			vcard.Addresses[i].DefaultPostal = true
+			return
+		}
+	}
+	vcard.Extras = append(vcard.Extras, contentLine)
+}
+
+// write X-MS-OL-DEFAULT-POSTAL-ADDRESS for the first DefaultPostal address
+func (vcard *VCard) writeDefaultPostal(di *DirectoryInfoWriter) {
+	for i := range vcard.Addresses {
+		if vcard.Addresses[i].DefaultPostal {
+			di.WriteContentLine(&ContentLine{"", "X-MS-OL-DEFAULT-POSTAL-ADDRESS", nil, StructuredValue{Value{outlookPostal(&vcard.Addresses[i])}}, nil, nil})
+			return
+		}
+	}
+}
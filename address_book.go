@@ -1,7 +1,7 @@
 package vcard
 
 import (
-	"log"
+	"strings"
 )
 
 type AddressBook struct {
@@ -15,21 +15,50 @@ func (ab *AddressBook) LastContact() *VCard {
 	return nil
 }
 
+// read the vcards of di, other components like VCALENDAR or VEVENT are
+// skipped but vcards nested in them are read
 func (ab *AddressBook) ReadFrom(di *DirectoryInfoReader) {
-	contentLine := di.ReadContentLine()
-	for contentLine != nil {
-		switch contentLine.Name {
+	for vcard := di.ReadVCard(); vcard != nil; vcard = di.ReadVCard() {
+		if last := ab.LastContact(); di.CoalesceUID && last != nil && last.UID != "" && last.UID == vcard.UID {
+			last.Merge(vcard)
+		} else {
+			ab.Contacts = append(ab.Contacts, *vcard)
+		}
+	}
+}
+
+// read the next vcard of di, nil at the end of input. other components
+// like VCALENDAR or VEVENT are skipped but vcards nested in them are read
+func (di *DirectoryInfoReader) ReadVCard() *VCard {
+	for contentLine := di.ReadContentLine(); contentLine != nil; contentLine = di.ReadContentLine() {
+		switch strings.ToUpper(contentLine.Name) {
 		case "BEGIN":
-			if contentLine.Value.GetText() == "VCARD" {
-				var vcard VCard
+			if contentLine.ComponentName() == "VCARD" {
+				vcard := new(VCard)
 				vcard.ReadFrom(di)
-				ab.Contacts = append(ab.Contacts, vcard)
+				return vcard
+			}
+			di.depth++
+		case "END":
+			if di.depth > 0 {
+				di.depth--
 			}
 		default:
-			log.Printf("Not read %s, %s: %s\n", contentLine.Group, contentLine.Name, contentLine.Value)
+			if di.depth == 0 && di.ImplicitBegin {
+				di.unread = contentLine
+				di.implicit = true
+				vcard := new(VCard)
+				vcard.ReadFrom(di)
+				di.implicit = false
+				return vcard
+			}
+			if di.depth == 0 {
+				di.stats.Skipped++
+				di.logf("Not read %s, %s: %s\n", contentLine.Group, contentLine.Name, contentLine.Value)
+			}
 		}
-		contentLine = di.ReadContentLine()
 	}
+	return nil
 }
 
 func (ab *AddressBook) WriteTo(di *DirectoryInfoWriter) {
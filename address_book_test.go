@@ -0,0 +1,66 @@
+package vcard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImplicitBegin(t *testing.T) {
+	for _, in := range []string{
+		"FN:a\r\nTEL:1",
+		"FN:a\r\nTEL:1\r\n",
+		"FN:a\nTEL:1\nEND:VCARD\n",
+	} {
+		di := NewDirectoryInfoReader(strings.NewReader(in))
+		di.ImplicitBegin = true
+		v := di.ReadVCard()
+		if v == nil || v.FormattedName != "a" || len(v.Telephones) != 1 || v.Telephones[0].Number != "1" {
+			t.Fatalf("%q: %+v", in, v)
+		}
+		if di.ReadVCard() != nil || len(di.Errors()) != 0 {
+			t.Fatalf("%q: %v", in, di.Errors())
+		}
+	}
+}
+
+func TestImplicitBeginNextCard(t *testing.T) {
+	di := NewDirectoryInfoReader(strings.NewReader("FN:a\r\nBEGIN:VCARD\r\nVERSION:3.0\r\nFN:b\r\nEND:VCARD"))
+	di.ImplicitBegin = true
+	var ab AddressBook
+	ab.ReadFrom(di)
+	if len(ab.Contacts) != 2 || ab.Contacts[0].FormattedName != "a" || ab.Contacts[1].FormattedName != "b" || len(di.Errors()) != 0 {
+		t.Fatalf("%+v %v", ab.Contacts, di.Errors())
+	}
+}
+
+func TestImplicitBeginDisabled(t *testing.T) {
+	di := NewDirectoryInfoReader(strings.NewReader("FN:a\r\nTEL:1"))
+	if v := di.ReadVCard(); v != nil {
+		t.Fatalf("%+v", v)
+	}
+	if di.Stats().Skipped != 2 {
+		t.Fatal(di.Stats())
+	}
+}
+
+func TestUnmarshalLenientRecovery(t *testing.T) {
+	cards, errs := UnmarshalLenient(strings.NewReader("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\n;;garbage\r\nno colon\r\nBEGIN:VCARD\r\nVERSION:3.0\r\nFN:b\r\nEND:VCARD\r\nBEGIN:VCARD\r\nVERSION:3.0\r\nFN:c\r\nEND:VCARD\r\n"))
+	if len(cards) != 3 || cards[0].FormattedName != "a" || cards[1].FormattedName != "b" || cards[2].FormattedName != "c" {
+		t.Fatalf("%+v", cards)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "has no END") {
+		t.Fatal(errs)
+	}
+	var logger captureLogger
+	di := NewDirectoryInfoReader(strings.NewReader("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nTEL;TYPE=cell\r\nNOTE:a\r\nEND:VCARD\r\nBEGIN:VCARD\r\nVERSION:3.0\r\nFN:b\r\nEND:VCARD\r\n"))
+	di.Logger = &logger
+	if v := di.ReadVCard(); v == nil || v.FormattedName != "a" || v.Note != "a" || len(v.Telephones) != 0 {
+		t.Fatalf("%+v", v)
+	}
+	if v := di.ReadVCard(); v == nil || v.FormattedName != "b" {
+		t.Fatalf("%+v", v)
+	}
+	if len(logger.messages) != 1 || logger.messages[0] != "Malformed line: TEL\n" {
+		t.Fatalf("%q", logger.messages)
+	}
+}
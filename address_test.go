@@ -0,0 +1,52 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want Address
+	}{
+		{"123 Main St, Apt 4, Springfield, IL 62704, USA", Address{Street: "123 Main St", ExtendedAddress: "Apt 4", Locality: "Springfield", Region: "IL", PostalCode: "62704", CountryName: "USA"}},
+		{"Springfield, IL 62704", Address{Locality: "Springfield", Region: "IL", PostalCode: "62704"}},
+		{"Springfield, IL", Address{Locality: "Springfield", Region: "IL"}},
+		{"123 Main St, Springfield, USA", Address{Street: "123 Main St", Locality: "Springfield", CountryName: "USA"}},
+		{"123 Main St, Springfield", Address{Street: "123 Main St", Locality: "Springfield"}},
+		{"PO Box 12, Toronto, ON M5V 2T6, Canada", Address{PostOfficeBox: "PO Box 12", Locality: "Toronto", Region: "ON", PostalCode: "M5V 2T6", CountryName: "Canada"}},
+		{"123 Main St", Address{Street: "123 Main St"}},
+		{"", Address{}},
+	} {
+		got := ParseAddress(test.in)
+		if got.PostOfficeBox != test.want.PostOfficeBox || got.Street != test.want.Street || got.ExtendedAddress != test.want.ExtendedAddress ||
+			got.Locality != test.want.Locality || got.Region != test.want.Region || got.PostalCode != test.want.PostalCode || got.CountryName != test.want.CountryName {
+			t.Errorf("%q: %+v", test.in, got)
+		}
+		if formatted := got.Format(); formatted != test.in {
+			t.Errorf("%q formatted as %q", test.in, formatted)
+		}
+	}
+}
+
+func TestAddressLabelVersion(t *testing.T) {
+	v := &VCard{FormattedName: "a", GivenNames: []string{"a"}, Addresses: []Address{{Type: TypeSet{"home"}, Street: "1 Main St", Locality: "Springfield", Label: "1 Main St\nSpringfield"}}}
+	for version, want := range map[string]string{
+		"3.0": "\r\nADR;type=home:;;1 Main St;Springfield;;;\r\nLABEL;type=home:1 Main St\\nSpringfield\r\n",
+		"4.0": "\r\nADR;LABEL=1 Main St\\nSpringfield;type=home:;;1 Main St;Springfield;;;\r\n",
+	} {
+		var b bytes.Buffer
+		di := NewDirectoryInfoWriter(&b)
+		di.Version = version
+		v.WriteTo(di)
+		if !strings.Contains(b.String(), want) || version == "4.0" && strings.Contains(b.String(), "\r\nLABEL") {
+			t.Fatal(version, b.String())
+		}
+		read := readCard(t, b.String())
+		if len(read.Addresses) != 1 || read.Addresses[0].Label != v.Addresses[0].Label {
+			t.Fatalf("%s: %+v", version, read.Addresses)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package vcard
+
+import (
+	"strings"
+)
+
+// a representation of the name of a vcard, e.g: in another language or
+// script, linked to the other representations by the 4.0 ALTID parameter:
+//
+//	N;ALTID=1;LANGUAGE=en:Smith;John;;;
+//	N;ALTID=1;LANGUAGE=ru:Смит;Джон;;;
+type Name struct {
+	AltID             string
+	Language          string // LANGUAGE parameter, e.g: ru
+	FormattedName     string
+	FamilyNames       []string
+	GivenNames        []string
+	AdditionalNames   []string
+	HonorificNames    []string
+	HonorificSuffixes []string
+	// the representation read into the FN or N fields of the vcard, taken
+	// from them so that their edits are kept
+	primaryFN, primaryN bool
+}
+
+// return true if any N component is set
+func (name *Name) hasName() bool {
+	for _, names := range [][]string{name.FamilyNames, name.GivenNames, name.AdditionalNames, name.HonorificNames, name.HonorificSuffixes} {
+		for _, n := range names {
+			if n != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// return the alternate name of the ALTID and LANGUAGE of the content line,
+// added if missing, nil if the content line has no ALTID
+func (vcard *VCard) altName(contentLine *ContentLine) *Name {
+	altID, _ := contentLine.Param("ALTID")
+	if altID.GetText() == "" {
+		return nil
+	}
+	language, _ := contentLine.Param("LANGUAGE")
+	for i := range vcard.Alternates {
+		alt := &vcard.Alternates[i]
+		if alt.AltID == altID.GetText() && strings.EqualFold(alt.Language, language.GetText()) {
+			return alt
+		}
+	}
+	vcard.Alternates = append(vcard.Alternates, Name{AltID: altID.GetText(), Language: language.GetText()})
+	return &vcard.Alternates[len(vcard.Alternates)-1]
+}
+
+// return the alternate representations of the name grouped by ALTID
+func (vcard *VCard) AltNames() map[string][]Name {
+	names := make(map[string][]Name)
+	for i := range vcard.Alternates {
+		alt := vcard.alternate(i)
+		names[alt.AltID] = append(names[alt.AltID], alt)
+	}
+	return names
+}
+
+// return the alternate name i, its FN or N taken from the vcard fields if
+// they were read from it
+func (vcard *VCard) alternate(i int) Name {
+	alt := vcard.Alternates[i]
+	if alt.primaryFN {
+		alt.FormattedName = vcard.FormattedName
+	}
+	if alt.primaryN {
+		alt.FamilyNames = vcard.FamilyNames
+		alt.GivenNames = vcard.GivenNames
+		alt.AdditionalNames = vcard.AdditionalNames
+		alt.HonorificNames = vcard.HonorificNames
+		alt.HonorificSuffixes = vcard.HonorificSuffixes
+	}
+	return alt
+}
+
+// write the FN and N of the alternate names of a 4.0 vcard. the FN and N
+// fields of the vcard are written with the parameters of the alternate
+// they were read from, without ALTID otherwise. SORT-AS goes on N, or on
+// FN for the cards without N
+func (vcard *VCard) writeAltNames(di *DirectoryInfoWriter, writeName bool, sortAs map[string]Value) {
+	primaryFN, primaryN, hasN := false, false, false
+	for i := range vcard.Alternates {
+		alt := vcard.alternate(i)
+		primaryFN = primaryFN || alt.primaryFN
+		primaryN = primaryN || alt.primaryN && alt.hasName()
+		hasN = hasN || alt.hasName()
+	}
+	fnSortAs := sortAs
+	if hasN || writeName {
+		fnSortAs = nil
+	}
+	if !primaryFN {
+		di.WriteContentLine(&ContentLine{"", "FN", fnSortAs, StructuredValue{Value{di.formattedName(vcard)}}, nil, nil})
+	}
+	for i := range vcard.Alternates {
+		alt := vcard.alternate(i)
+		if alt.primaryFN {
+			alt.FormattedName = di.formattedName(vcard)
+		}
+		if alt.FormattedName != "" || alt.primaryFN {
+			di.WriteContentLine(&ContentLine{"", "FN", alt.params(fnSortAs), StructuredValue{Value{alt.FormattedName}}, nil, nil})
+		}
+	}
+	if !primaryN && writeName {
+		di.WriteContentLine(&ContentLine{"", "N", sortAs, vcard.nameValue(), nil, nil})
+	}
+	for i := range vcard.Alternates {
+		alt := vcard.alternate(i)
+		if !alt.hasName() {
+			continue
+		}
+		name := StructuredValue{alt.FamilyNames, alt.GivenNames, alt.AdditionalNames, alt.HonorificNames, alt.HonorificSuffixes}
+		if alt.primaryN {
+			name = vcard.nameValue()
+		}
+		di.WriteContentLine(&ContentLine{"", "N", alt.params(sortAs), name, nil, nil})
+	}
+}
+
+// return the N value of the vcard, its extra components included
+func (vcard *VCard) nameValue() StructuredValue {
+	name := StructuredValue{vcard.FamilyNames, vcard.GivenNames, vcard.AdditionalNames, vcard.HonorificNames, vcard.HonorificSuffixes}
+	for _, extra := range vcard.NameExtra {
+		name = append(name, Value{extra})
+	}
+	return name
+}
+
+// return the ALTID and LANGUAGE parameters of the name added to params
+func (name *Name) params(params map[string]Value) map[string]Value {
+	p := map[string]Value{"ALTID": {name.AltID}}
+	if name.Language != "" {
+		p["LANGUAGE"] = Value{name.Language}
+	}
+	for key, value := range params {
+		p[key] = value
+	}
+	return p
+}
+
+// return a deep copy of the names
+func copyNames(names []Name) []Name {
+	if names == nil {
+		return nil
+	}
+	c := make([]Name, len(names))
+	for i, name := range names {
+		name.FamilyNames = copyStrings(name.FamilyNames)
+		name.GivenNames = copyStrings(name.GivenNames)
+		name.AdditionalNames = copyStrings(name.AdditionalNames)
+		name.HonorificNames = copyStrings(name.HonorificNames)
+		name.HonorificSuffixes = copyStrings(name.HonorificSuffixes)
+		c[i] = name
+	}
+	return c
+}
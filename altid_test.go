@@ -0,0 +1,65 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAltNamesEdit(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:4.0\r\nFN;ALTID=1;LANGUAGE=en:John Smith\r\nFN;ALTID=1;LANGUAGE=ru:Джон Смит\r\nN;ALTID=1;LANGUAGE=en:Smith;John;;;;x\r\nN;ALTID=1;LANGUAGE=ru:Смит;Джон;;;\r\nEND:VCARD\r\n")
+	v.FormattedName = "Johnny Smith"
+	v.FamilyNames = []string{"Smyth"}
+	var b bytes.Buffer
+	if err := v.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, line := range []string{
+		"\r\nFN;ALTID=1;LANGUAGE=en:Johnny Smith\r\n",
+		"\r\nFN;ALTID=1;LANGUAGE=ru:Джон Смит\r\n",
+		"\r\nN;ALTID=1;LANGUAGE=en:Smyth;John;;;;x\r\n",
+		"\r\nN;ALTID=1;LANGUAGE=ru:Смит;Джон;;;\r\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Fatal(line, out)
+		}
+	}
+	if strings.Count(out, "\r\nFN") != 2 || strings.Count(out, "\r\nN") != 2 {
+		t.Fatal(out)
+	}
+	if en := v.AltNames()["1"][0]; en.FormattedName != "Johnny Smith" || en.FamilyNames[0] != "Smyth" {
+		t.Fatalf("%+v", en)
+	}
+}
+
+func TestAltNamesPlainFN(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:John Smith\r\nFN;ALTID=1;LANGUAGE=ru:Джон Смит\r\nEND:VCARD\r\n")
+	var b bytes.Buffer
+	if err := v.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if out := b.String(); !strings.Contains(out, "\r\nFN:John Smith\r\nFN;ALTID=1;LANGUAGE=ru:Джон Смит\r\n") {
+		t.Fatal(out)
+	}
+}
+
+func TestRedactAltNames(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:4.0\r\nFN;ALTID=1;LANGUAGE=en:John Smith\r\nFN;ALTID=1;LANGUAGE=ru:Джон Смит\r\nN;ALTID=1;LANGUAGE=en:Smith;John;;;\r\nN;ALTID=1;LANGUAGE=ru:Смит;Джон;;;\r\nEND:VCARD\r\n")
+	var b bytes.Buffer
+	if err := v.Redact("FN").Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	if strings.Contains(out, "\r\nN") || strings.Count(out, "\r\nFN;ALTID=1") != 2 {
+		t.Fatal(out)
+	}
+	b.Reset()
+	if err := v.Redact("N").Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	out = b.String()
+	if strings.Contains(out, "Джон Смит") || strings.Count(out, "\r\nN;ALTID=1") != 2 {
+		t.Fatal(out)
+	}
+}
@@ -0,0 +1,205 @@
+package vcard
+
+import (
+	"strconv"
+	"strings"
+)
+
+// a 1x1 transparent GIF replacing anonymized photos
+const placeholderPhoto = "R0lGODlhAQABAIAAAAAAAP///yH5BAEAAAAALAAAAAABAAEAAAIBRAA7"
+
+// replace the non empty strings by prefix followed by their position, e.g:
+// Given1, Given2
+func anonymizeStrings(ss []string, prefix string) {
+	for i := range ss {
+		if ss[i] != "" {
+			ss[i] = prefix + strconv.Itoa(i+1)
+		}
+	}
+}
+
+func anonymizeString(s *string, fake string) {
+	if *s != "" {
+		*s = fake
+	}
+}
+
+// replace a date, keeping the dates without year as such, e.g: --0412
+func anonymizeDate(date *string) {
+	if strings.HasPrefix(*date, "--") {
+		anonymizeString(date, "--0101")
+	} else {
+		anonymizeString(date, "2000-01-01")
+	}
+}
+
+// replace a custom label, the Apple standard labels like Work are kept
+func anonymizeLabel(label *string, n int) {
+	for _, l := range abStandardLabels {
+		if strings.EqualFold(l, *label) {
+			return
+		}
+	}
+	anonymizeString(label, "Label "+strconv.Itoa(n))
+}
+
+// replace a URI keeping its scheme, e.g: xmpp:user1@example.com
+func anonymizeURI(uri *string, n int) {
+	scheme := "http"
+	if i := strings.Index(*uri, ":"); i > 0 {
+		scheme = (*uri)[:i]
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https":
+		anonymizeString(uri, scheme+"://example.com/"+strconv.Itoa(n))
+	default:
+		anonymizeString(uri, scheme+":user"+strconv.Itoa(n)+"@example.com")
+	}
+}
+
+// extra properties whose value is kept by Anonymize, which carry no
+// personal data
+var anonymousExtras = map[string]bool{
+	"PRODID": true, "CLASS": true, "PROFILE": true, "X-MS-OL-DEFAULT-POSTAL-ADDRESS": true,
+}
+
+// parameters of the extra properties whose values are kept by Anonymize
+var anonymousParams = map[string]bool{
+	"TYPE": true, "VALUE": true, "ENCODING": true, "CHARSET": true, "PREF": true,
+}
+
+// replace the value and the parameter values of an extra property n by
+// placeholders, keeping its name, group, types and component count
+func anonymizeExtra(extra *ContentLine, n int) {
+	name := strings.ToUpper(extra.Name)
+	if anonymousExtras[name] {
+		return
+	}
+	for key, values := range extra.Params {
+		if !anonymousParams[strings.ToUpper(key)] {
+			anonymizeStrings(values, "Param")
+		}
+	}
+	for _, value := range extra.Value {
+		for i := range value {
+			switch {
+			case value[i] == "":
+			case name == "X-ABLABEL":
+				label := decodeABLabel(value[i])
+				anonymizeLabel(&label, n)
+				value[i] = encodeABLabel(label, isWrappedABLabel(value[i]))
+			case binaryProperties[name] && !isURIValue(extra.Params):
+				value[i] = placeholderPhoto
+			default:
+				value[i] = "Extra " + strconv.Itoa(n)
+			}
+		}
+	}
+}
+
+// return a copy of the vcard whose names, nicknames, organization, titles,
+// roles, addresses, emails, telephones, messaging addresses, URLs, dates,
+// custom labels, note and photo are replaced by fake data, e.g: to share a
+// bug report. the result
+// only depends on the vcard structure: the properties, their count, types
+// and parameters are kept. the values of the extra properties are replaced
+// too, but for a few without personal data like PRODID
+func (vcard *VCard) Anonymize() *VCard {
+	c := vcard.Clone()
+	anonymizeStrings(c.FamilyNames, "Family")
+	anonymizeStrings(c.GivenNames, "Given")
+	anonymizeStrings(c.AdditionalNames, "Additional")
+	anonymizeStrings(c.HonorificNames, "Honorific")
+	anonymizeStrings(c.HonorificSuffixes, "Suffix")
+	anonymizeStrings(c.NameExtra, "Extra")
+	anonymizeStrings(c.SortAs, "Sort")
+	anonymizeStrings(c.NickNames, "Nick")
+	anonymizeStrings(c.Org, "Org")
+	anonymizeStrings(c.Titles, "Title")
+	anonymizeStrings(c.Roles, "Role")
+	anonymizeString(&c.PhoneticGiven, "Given1")
+	anonymizeString(&c.PhoneticFamily, "Family1")
+	if c.FormattedName != "" {
+		c.FormattedName = "Given1 Family1"
+	}
+	for i := range c.Alternates {
+		alt := &c.Alternates[i]
+		anonymizeStrings(alt.FamilyNames, "Family")
+		anonymizeStrings(alt.GivenNames, "Given")
+		anonymizeStrings(alt.AdditionalNames, "Additional")
+		anonymizeStrings(alt.HonorificNames, "Honorific")
+		anonymizeStrings(alt.HonorificSuffixes, "Suffix")
+		anonymizeString(&alt.FormattedName, "Given1 Family1")
+	}
+	if c.Photo.Data != "" {
+		if c.Photo.isURI() {
+			c.Photo.Data = "http://example.com/photo.gif"
+		} else {
+			c.Photo.Data = placeholderPhoto
+		}
+		c.Photo.Type = "GIF"
+	}
+	anonymizeDate(&c.Birthday)
+	anonymizeDate(&c.Anniversary)
+	anonymizeDate(&c.Deathdate)
+	if strings.EqualFold(c.BirthplaceValue, "uri") {
+		anonymizeString(&c.Birthplace, "geo:0,0")
+	} else {
+		anonymizeString(&c.Birthplace, "Birthplace")
+	}
+	anonymizeString(&c.Deathplace, "Deathplace")
+	anonymizeString(&c.Note, "Note")
+	anonymizeString(&c.UID, "urn:uuid:00000000-0000-0000-0000-000000000000")
+	anonymizeString(&c.XABuid, "00000000-0000-0000-0000-000000000000:ABPerson")
+	anonymizeString(&c.AgentURI, "urn:uuid:00000000-0000-0000-0000-000000000000")
+	for i := range c.ContactURIs {
+		anonymizeURI(&c.ContactURIs[i], i+1)
+	}
+	if c.Geo != nil {
+		c.Geo = &GeoCoord{}
+	}
+	for i := range c.Addresses {
+		addr := &c.Addresses[i]
+		n := strconv.Itoa(i + 1)
+		anonymizeString(&addr.Label, "Label "+n)
+		anonymizeString(&addr.PostOfficeBox, "PO Box "+n)
+		anonymizeString(&addr.ExtendedAddress, "Apt "+n)
+		anonymizeString(&addr.Street, n+" Example Street")
+		anonymizeString(&addr.Locality, "City "+n)
+		anonymizeString(&addr.Region, "Region "+n)
+		anonymizeString(&addr.PostalCode, "0000"+n)
+		anonymizeString(&addr.CountryName, "Country "+n)
+		anonymizeStrings(addr.Extra, "Extra")
+		addr.Geo = nil
+	}
+	for i := range c.Telephones {
+		// 555-01xx numbers are reserved for fiction
+		c.Telephones[i].Number = "+1 555 01" + strconv.Itoa(i%90+10)
+		anonymizeLabel(&c.Telephones[i].Label, i+1)
+	}
+	for i := range c.Emails {
+		c.Emails[i].Address = "user" + strconv.Itoa(i+1) + "@example.com"
+		c.Emails[i].Original = ""
+		anonymizeLabel(&c.Emails[i].Label, i+1)
+	}
+	for i := range c.URLs {
+		anonymizeURI(&c.URLs[i].Value, i+1)
+		anonymizeLabel(&c.URLs[i].Label, i+1)
+	}
+	for i := range c.XJabbers {
+		anonymizeString(&c.XJabbers[i].Address, "user"+strconv.Itoa(i+1)+"@example.com")
+		anonymizeLabel(&c.XJabbers[i].Label, i+1)
+	}
+	for i := range c.IMPPs {
+		anonymizeURI(&c.IMPPs[i].URI, i+1)
+		anonymizeLabel(&c.IMPPs[i].Label, i+1)
+	}
+	for i := range c.CustomDates {
+		anonymizeDate(&c.CustomDates[i].Date)
+		anonymizeLabel(&c.CustomDates[i].Label, i+1)
+	}
+	for i, extra := range c.Extras {
+		anonymizeExtra(extra, i+1)
+	}
+	return c
+}
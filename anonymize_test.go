@@ -0,0 +1,43 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAnonymize(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nPRODID:-//Apple Inc.//Mac OS X 10.15//EN\r\nFN:Dr. John Smith\r\nORG:Acme;Sales\r\nTITLE:CEO of Acme\r\nROLE:Smith family\r\nN:Smith;John;;Dr.;Jr.\r\nNOTE:met at Acme\r\nBDAY:1980-05-12\r\nUID:1234-smith\r\nIMPP;X-SERVICE-TYPE=Skype:skype:jsmith\r\nX-JABBER:jsmith@jabber.org\r\nURL:http://smith.example.org\r\nitem1.TEL:+33 1 23 45 67 89\r\nitem1.X-ABLabel:Mum's cell\r\nitem2.X-ABRELATEDNAMES:Jane Smith\r\nitem2.X-ABLabel:_$!<Spouse>!$_\r\nX-SOCIALPROFILE;type=twitter:http://twitter.com/jsmith\r\nEND:VCARD\r\n")
+	anonymized := v.Anonymize()
+	if count, anonymizedCount := countProperties(v), countProperties(anonymized); len(count) != len(anonymizedCount) {
+		t.Fatal(count, anonymizedCount)
+	} else {
+		for name, n := range count {
+			if anonymizedCount[name] != n {
+				t.Fatal(count, anonymizedCount)
+			}
+		}
+	}
+	var b bytes.Buffer
+	if err := anonymized.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, s := range []string{"Smith", "smith", "Dr.", "Jr.", "Acme", "1980", "Mum", "CEO", "Sales"} {
+		if strings.Contains(out, s) {
+			t.Fatal(s, out)
+		}
+	}
+	if !strings.Contains(out, "\r\nPRODID:") || !strings.Contains(out, "skype:user1@example.com\r\n") {
+		t.Fatal(out)
+	}
+}
+
+// return the count of the properties of the vcard by name
+func countProperties(v *VCard) map[string]int {
+	count := make(map[string]int)
+	for _, p := range v.Properties() {
+		count[strings.ToUpper(p.Name)]++
+	}
+	return count
+}
@@ -0,0 +1,203 @@
+package vcard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Apple Address Book associate a custom label to a property by putting
+// both in the same group, e.g:
+//	item1.EMAIL;type=INTERNET:john@example.com
+//	item1.X-ABLabel:_$!<Work>!$_
+// standard labels are wrapped in the "_$!<" and ">!$_" markers
+
+const (
+	abLabelPrefix = "_$!<"
+	abLabelSuffix = ">!$_"
+)
+
+// labels known by Apple Address Book, written back with the markers
+var abStandardLabels = []string{
+	"Home", "Work", "Other", "Mobile", "Main", "Pager",
+	"HomeFAX", "WorkFAX", "OtherFAX", "HomePage", "Anniversary",
+	"Father", "Mother", "Parent", "Brother", "Sister", "Child",
+	"Friend", "Spouse", "Partner", "Assistant", "Manager",
+}
+
+// return true if the X-ABLabel token is wrapped in the markers
+func isWrappedABLabel(label string) bool {
+	return strings.HasPrefix(label, abLabelPrefix) && strings.HasSuffix(label, abLabelSuffix) &&
+		len(label) >= len(abLabelPrefix)+len(abLabelSuffix)
+}
+
+// translate an X-ABLabel token like "_$!<Work>!$_" to "Work",
+// custom labels are returned unchanged
+func decodeABLabel(label string) string {
+	if isWrappedABLabel(label) {
+		return label[len(abLabelPrefix) : len(label)-len(abLabelSuffix)]
+	}
+	return label
+}
+
+// wrap a standard label in the X-ABLabel markers, or any label read
+// wrapped, e.g: a token newer than abStandardLabels. other custom labels
+// are returned unchanged
+func encodeABLabel(label string, wrapped bool) string {
+	if wrapped {
+		return abLabelPrefix + label + abLabelSuffix
+	}
+	for _, l := range abStandardLabels {
+		if strings.EqualFold(l, label) {
+			return abLabelPrefix + l + abLabelSuffix
+		}
+	}
+	return label
+}
+
+// renumber the groups of a vcard as it is written so they are sequential
+// itemN groups, e.g: after merging cards whose groups collide
+type groupNumbering struct {
+	item    int
+	groups  map[string]string // group read to group written
+	claimed map[string]bool   // groups read already used by a property
+}
+
+func (gn *groupNumbering) next() string {
+	gn.item++
+	return fmt.Sprintf("item%d", gn.item)
+}
+
+// return the group to write a property in, a new group is allocated if
+// the property has a label but no group, or if its group is already used
+// by another property
+func (gn *groupNumbering) property(group, label string) string {
+	if group == "" {
+		if label == "" {
+			return ""
+		}
+		return gn.next()
+	}
+	if gn.claimed == nil {
+		gn.claimed = make(map[string]bool)
+	}
+	if gn.claimed[group] {
+		return gn.next()
+	}
+	gn.claimed[group] = true
+	return gn.extra(group)
+}
+
+// return the group to write an extra property in, extra properties
+// sharing a group with a property stay in the group of the property
+func (gn *groupNumbering) extra(group string) string {
+	if group == "" {
+		return ""
+	}
+	if g, ok := gn.groups[group]; ok {
+		return g
+	}
+	if gn.groups == nil {
+		gn.groups = make(map[string]string)
+	}
+	g := gn.next()
+	gn.groups[group] = g
+	return g
+}
+
+// write the X-ABLabel line associated to a grouped property
+func writeABLabel(di *DirectoryInfoWriter, group, label string, wrapped bool) {
+	if group == "" || label == "" {
+		return
+	}
+	di.WriteContentLine(&ContentLine{group, "X-ABLabel", nil, StructuredValue{Value{encodeABLabel(label, wrapped)}}, nil, nil})
+}
+
+// set the labels read from X-ABLabel lines on the properties of the same
+// group, whatever the vcard version. labels of groups without modeled
+// property, e.g: item1.X-ABRELATEDNAMES or a grouped 4.0 RELATED, are kept
+// in the extras
+func (vcard *VCard) resolveLabels(labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+	claimed := make(map[string]bool)
+	claim := func(group string, label *string, wrapped *bool) {
+		if l, ok := labels[group]; ok {
+			*label, *wrapped = decodeABLabel(l), isWrappedABLabel(l)
+			claimed[group] = true
+		}
+	}
+	for i := range vcard.Telephones {
+		claim(vcard.Telephones[i].Group, &vcard.Telephones[i].Label, &vcard.Telephones[i].labelWrapped)
+	}
+	for i := range vcard.Emails {
+		claim(vcard.Emails[i].Group, &vcard.Emails[i].Label, &vcard.Emails[i].labelWrapped)
+	}
+	for i := range vcard.URLs {
+		claim(vcard.URLs[i].Group, &vcard.URLs[i].Label, &vcard.URLs[i].labelWrapped)
+	}
+	for i := range vcard.XJabbers {
+		claim(vcard.XJabbers[i].Group, &vcard.XJabbers[i].Label, &vcard.XJabbers[i].labelWrapped)
+	}
+	for i := range vcard.IMPPs {
+		claim(vcard.IMPPs[i].Group, &vcard.IMPPs[i].Label, &vcard.IMPPs[i].labelWrapped)
+	}
+	for i := range vcard.CustomDates {
+		claim(vcard.CustomDates[i].Group, &vcard.CustomDates[i].Label, &vcard.CustomDates[i].labelWrapped)
+	}
+	var groups []string
+	for group := range labels {
+		if !claimed[group] {
+			groups = append(groups, group)
+		}
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		vcard.Extras = append(vcard.Extras, &ContentLine{group, "X-ABLabel", nil, StructuredValue{Value{labels[group]}}, nil, nil})
+	}
+}
+
+// return the label of a property group, e.g: "Work" for item1 when the card
+// has a item1.X-ABLabel:_$!<Work>!$_ line, empty if the group has no label
+func (vcard *VCard) GroupLabel(group string) string {
+	if group == "" {
+		return ""
+	}
+	for _, tel := range vcard.Telephones {
+		if tel.Group == group && tel.Label != "" {
+			return tel.Label
+		}
+	}
+	for _, email := range vcard.Emails {
+		if email.Group == group && email.Label != "" {
+			return email.Label
+		}
+	}
+	for _, url := range vcard.URLs {
+		if url.Group == group && url.Label != "" {
+			return url.Label
+		}
+	}
+	for _, jab := range vcard.XJabbers {
+		if jab.Group == group && jab.Label != "" {
+			return jab.Label
+		}
+	}
+	for _, impp := range vcard.IMPPs {
+		if impp.Group == group && impp.Label != "" {
+			return impp.Label
+		}
+	}
+	for _, date := range vcard.CustomDates {
+		if date.Group == group && date.Label != "" {
+			return date.Label
+		}
+	}
+	for _, extra := range vcard.Extras {
+		if extra.Group == group && strings.EqualFold(extra.Name, "X-ABLabel") {
+			return decodeABLabel(extra.Value.GetText())
+		}
+	}
+	return ""
+}
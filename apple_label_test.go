@@ -0,0 +1,27 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestABLabelRoundTrip(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nN:a;;;;\r\nitem1.TEL:1234\r\nitem1.X-ABLabel:_$!<CompanyMain>!$_\r\nitem2.URL:http://a.b\r\nitem2.X-ABLabel:_$!<HomePage>!$_\r\nEND:VCARD\r\n")
+	if v.Telephones[0].Label != "CompanyMain" {
+		t.Fatal(v.Telephones[0].Label)
+	}
+	var b bytes.Buffer
+	if err := v.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, line := range []string{
+		"\r\nitem1.X-ABLabel:_$!<CompanyMain>!$_\r\n",
+		"\r\nitem2.X-ABLabel:_$!<HomePage>!$_\r\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Fatal(line, out)
+		}
+	}
+}
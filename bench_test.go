@@ -0,0 +1,45 @@
+package vcard
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// a generated address book of n cards with parameters, escapes, folded
+// and quoted-printable lines
+func benchmarkData(n int) []byte {
+	var b bytes.Buffer
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:John Doe %d\r\nN:Doe;John;;;\r\n", i)
+		fmt.Fprintf(&b, "TEL;TYPE=CELL:+1 555 %04d\r\nEMAIL;TYPE=INTERNET,HOME:john%d@example.com\r\n", i%10000, i)
+		b.WriteString("ADR;TYPE=HOME:;;1 Main St;Springfield;IL;62704;USA\r\n")
+		b.WriteString("NOTE:a note long enough to be folded by the writer\\, with escapes and\r\n  a continuation line\r\n")
+		b.WriteString("TITLE;ENCODING=QUOTED-PRINTABLE:Caf=C3=A9 owner\r\nORG:Example Inc;Sales\r\nEND:VCARD\r\n")
+	}
+	return b.Bytes()
+}
+
+func BenchmarkReadContentLine(b *testing.B) {
+	data := benchmarkData(1000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		di := NewDirectoryInfoReader(bytes.NewReader(data))
+		for di.ReadContentLine() != nil {
+		}
+	}
+}
+
+func BenchmarkUnmarshalAll(b *testing.B) {
+	data := benchmarkData(1000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := UnmarshalAll(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package vcard
+
+import (
+	"strings"
+)
+
+// a problem found or fixed by Canonicalize
+type Warning struct {
+	Property string // e.g: EMAIL
+	Message  string
+}
+
+func (w Warning) String() string {
+	return w.Property + ": " + w.Message
+}
+
+func trimStrings(ss []string) {
+	for i := range ss {
+		ss[i] = strings.TrimSpace(ss[i])
+	}
+}
+
+// return the strings without case-insensitive duplicates, first one kept
+func dedupeStrings(ss []string) []string {
+	var deduped []string
+	for _, s := range ss {
+		found := false
+		for _, d := range deduped {
+			if strings.EqualFold(d, s) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			deduped = append(deduped, s)
+		}
+	}
+	return deduped
+}
+
+// return a cleaned copy of the vcard: values are trimmed, types and emails
+// normalized, categories deduplicated and a missing FN derived from N, ORG
+// or the email. the warnings report the changes and remaining problems
+func Canonicalize(v *VCard) (*VCard, []Warning) {
+	var warnings []Warning
+	c := v.Clone()
+	c.FormattedName = strings.TrimSpace(c.FormattedName)
+	trimStrings(c.FamilyNames)
+	trimStrings(c.GivenNames)
+	trimStrings(c.AdditionalNames)
+	trimStrings(c.HonorificNames)
+	trimStrings(c.HonorificSuffixes)
+	trimStrings(c.NickNames)
+	trimStrings(c.Org)
+	trimStrings(c.Categories)
+	c.Note = strings.TrimSpace(c.Note)
+	if categories := dedupeStrings(c.Categories); len(categories) != len(c.Categories) {
+		warnings = append(warnings, Warning{"CATEGORIES", "duplicate categories removed"})
+		c.Categories = categories
+	}
+	for i := range c.Addresses {
+		c.Addresses[i].Type.Normalize()
+	}
+	for i := range c.Telephones {
+		c.Telephones[i].Type.Normalize()
+		c.Telephones[i].Number = strings.TrimSpace(c.Telephones[i].Number)
+	}
+	for i := range c.Emails {
+		c.Emails[i].Type.Normalize()
+		if err := c.Emails[i].Normalize(); err != nil {
+			warnings = append(warnings, Warning{"EMAIL", err.Error()})
+		}
+	}
+	for i := range c.URLs {
+		c.URLs[i].Type.Normalize()
+		c.URLs[i].Value = strings.TrimSpace(c.URLs[i].Value)
+	}
+	for i := range c.IMPPs {
+		c.IMPPs[i].Type.Normalize()
+		c.IMPPs[i].URI = strings.TrimSpace(c.IMPPs[i].URI)
+	}
+	for i := range c.XJabbers {
+		c.XJabbers[i].Type.Normalize()
+		c.XJabbers[i].Address = strings.TrimSpace(c.XJabbers[i].Address)
+	}
+	if c.FormattedName == "" {
+		names := append(append(copyStrings(c.GivenNames), c.AdditionalNames...), c.FamilyNames...)
+		c.FormattedName = strings.Join(strings.Fields(strings.Join(names, " ")), " ")
+		if c.FormattedName == "" && len(c.Org) > 0 {
+			c.FormattedName = c.Org[0]
+		}
+		if c.FormattedName == "" {
+			c.FormattedName = c.PrimaryEmail()
+		}
+		if c.FormattedName != "" {
+			warnings = append(warnings, Warning{"FN", "missing FN derived as " + c.FormattedName})
+		}
+	}
+	for _, err := range c.Validate() {
+		warnings = append(warnings, Warning{"", err.Error()})
+	}
+	return c, warnings
+}
@@ -0,0 +1,319 @@
+package vcard
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"unicode/utf8"
+)
+
+// characters 0x80 to 0x9F of Windows-1252, the others match ISO-8859-1
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// characters of ISO-8859-15 differing from ISO-8859-1
+var iso885915 = map[byte]rune{
+	0xA4: '€', 0xA6: 'Š', 0xA8: 'š', 0xB4: 'Ž', 0xB8: 'ž', 0xBC: 'Œ', 0xBD: 'œ', 0xBE: 'Ÿ',
+}
+
+// return the function decoding a byte of the single byte charset,
+// nil if the charset is unknown
+func charsetDecoder(charset string) func(byte) rune {
+	switch strings.ToUpper(strings.TrimSpace(charset)) {
+	case "WINDOWS-1252", "CP1252":
+		return func(b byte) rune {
+			if b >= 0x80 && b < 0xA0 {
+				return windows1252[b-0x80]
+			}
+			return rune(b)
+		}
+	case "ISO-8859-1", "LATIN1":
+		return func(b byte) rune {
+			return rune(b)
+		}
+	case "ISO-8859-15", "LATIN9":
+		return func(b byte) rune {
+			if r, ok := iso885915[b]; ok {
+				return r
+			}
+			return rune(b)
+		}
+	}
+	return nil
+}
+
+// the bytes 0x80 to 0xFF which are not valid UTF-8 are escaped as the
+// private use characters U+F780 to U+F7FF until their charset is known.
+// the byte following the lead byte of a double-byte charset is escaped too,
+// as U+F700 to U+F77F if ASCII, e.g: the 0x5C of Shift_JIS 表 is not a
+// backslash. the bytes of the characters U+F700 to U+F7FF read are escaped
+// so that unescapeBytes restores them
+const escapedByteBase = 0xF700
+
+// return the function telling if two bytes are the lead and trail bytes
+// of a character of the double-byte charset, nil if the charset is not
+// known as double-byte. line breaks are never trail bytes
+func doubleByte(charset string) func(lead, trail byte) bool {
+	switch strings.ToUpper(strings.TrimSpace(charset)) {
+	case "SHIFT_JIS", "SHIFT-JIS", "SJIS", "CP932", "WINDOWS-31J", "MS_KANJI":
+		return func(lead, trail byte) bool {
+			return (lead >= 0x81 && lead <= 0x9F || lead >= 0xE0 && lead <= 0xFC) && trail >= 0x40 && trail <= 0xFC && trail != 0x7F
+		}
+	case "BIG5", "BIG5-HKSCS", "GBK", "GB18030", "CP936":
+		return func(lead, trail byte) bool {
+			return lead >= 0x81 && lead <= 0xFE && trail >= 0x40 && trail <= 0xFE && trail != 0x7F
+		}
+	}
+	return nil
+}
+
+// transcode the bytes which are not valid UTF-8 from the charset to UTF-8,
+// valid UTF-8 is passed through
+type charsetReader struct {
+	reader  io.Reader
+	charset *string // e.g: the AssumeCharset reader option
+	// if set, the bytes not transcoded are escaped, e.g: the DefaultCharset
+	// reader option
+	escape  *string
+	decoder func(byte) rune
+	logf    func(format string, v ...interface{})
+	// count of the bytes transcoded, e.g: the ParseStats of the reader
+	transcoded *int
+	buf        []byte
+	pending    []byte // incomplete UTF-8 sequence at the end of the last read
+	out        []byte // transcoded bytes not returned yet
+}
+
+func (cr *charsetReader) Read(p []byte) (int, error) {
+	if len(cr.out) == 0 && len(cr.pending) == 0 && *cr.charset == "" && *cr.escape == "" {
+		return cr.reader.Read(p)
+	}
+	if cr.decoder == nil && *cr.charset != "" {
+		if cr.decoder = charsetDecoder(*cr.charset); cr.decoder == nil {
+			cr.logf("Unknown charset %s\n", *cr.charset)
+			*cr.charset = ""
+		}
+	}
+	var err error
+	for len(cr.out) == 0 && err == nil {
+		if cap(cr.buf) < len(p) {
+			cr.buf = make([]byte, len(p))
+		}
+		var n int
+		n, err = cr.reader.Read(cr.buf[:len(p)])
+		data := append(cr.pending, cr.buf[:n]...)
+		cr.pending = nil
+		for i := 0; i < len(data); {
+			r, size := utf8.DecodeRune(data[i:])
+			if r == utf8.RuneError && size <= 1 {
+				if err == nil && !utf8.FullRune(data[i:]) {
+					cr.pending = append([]byte(nil), data[i:]...)
+					break
+				}
+				if cr.decoder != nil {
+					cr.out = utf8.AppendRune(cr.out, cr.decoder(data[i]))
+					*cr.transcoded++
+				} else if *cr.escape != "" {
+					n := 1
+					if pair := doubleByte(*cr.escape); pair != nil {
+						// the trail byte is read with the lead byte
+						if i+1 == len(data) && err == nil {
+							cr.pending = append([]byte(nil), data[i:]...)
+							break
+						}
+						if i+1 < len(data) && pair(data[i], data[i+1]) {
+							n = 2
+						}
+					}
+					for _, b := range data[i : i+n] {
+						cr.out = utf8.AppendRune(cr.out, escapedByteBase+rune(b))
+					}
+					i += n
+					continue
+				} else {
+					cr.out = append(cr.out, data[i])
+				}
+				i++
+				continue
+			}
+			if *cr.escape != "" && r >= escapedByteBase && r <= escapedByteBase+0xFF {
+				for _, b := range data[i : i+size] {
+					cr.out = utf8.AppendRune(cr.out, escapedByteBase+rune(b))
+				}
+			} else {
+				cr.out = append(cr.out, data[i:i+size]...)
+			}
+			i += size
+		}
+	}
+	n := copy(p, cr.out)
+	cr.out = cr.out[n:]
+	if len(cr.out) > 0 {
+		return n, nil
+	}
+	cr.out = nil
+	return n, err
+}
+
+// return the byte a character decoded from Latin-1 or Windows-1252 was
+// read from, false if the character is in none of them
+func latin1Byte(r rune) (byte, bool) {
+	if r < 0x100 {
+		return byte(r), true
+	}
+	for i, c := range windows1252 {
+		if c == r {
+			return byte(0x80 + i), true
+		}
+	}
+	return 0, false
+}
+
+// repair UTF-8 text wrongly decoded as Latin-1 or Windows-1252 then encoded
+// again, e.g: "Ã©" is repaired as "é". the text is returned unchanged unless
+// all its characters are Latin-1 or Windows-1252 and their bytes are valid
+// UTF-8 with at least one non ASCII character
+func RepairMojibake(s string) string {
+	if strings.IndexFunc(s, func(r rune) bool { return r >= utf8.RuneSelf }) < 0 {
+		return s
+	}
+	repaired := make([]byte, 0, len(s))
+	for _, r := range s {
+		b, ok := latin1Byte(r)
+		if !ok {
+			return s
+		}
+		repaired = append(repaired, b)
+	}
+	if !utf8.Valid(repaired) {
+		return s
+	}
+	return string(repaired)
+}
+
+// repair the mojibake in the values of a content line, binary data excepted
+func repairContentLine(contentLine *ContentLine) {
+	if binaryProperties[strings.ToUpper(contentLine.Name)] {
+		return
+	}
+	for _, value := range contentLine.Value {
+		for i := range value {
+			value[i] = RepairMojibake(value[i])
+		}
+	}
+}
+
+// return the bytes of the text, the characters escaped by the charsetReader
+// being restored
+func unescapeBytes(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r >= escapedByteBase && r <= escapedByteBase+0xFF {
+			b = append(b, byte(r-escapedByteBase))
+		} else {
+			b = utf8.AppendRune(b, r)
+		}
+	}
+	return b
+}
+
+// return the parameter value read with the characters escaped by the
+// charsetReader transcoded from DefaultCharset, e.g: a Shift_JIS TYPE
+func (di *DirectoryInfoReader) unescapeParam(text string) string {
+	if di.DefaultCharset == "" {
+		return text
+	}
+	b := unescapeBytes(text)
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	decoded, err := di.decodeCharset(di.DefaultCharset, b)
+	if err != nil {
+		di.logf("Not transcoded parameter %q: %s\n", text, err)
+		return string(b)
+	}
+	di.stats.Transcoded += len(b)
+	return decoded
+}
+
+// decode the data from the charset to UTF-8
+func (di *DirectoryInfoReader) decodeCharset(charset string, data []byte) (string, error) {
+	if decode := charsetDecoder(charset); decode != nil {
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = decode(b)
+		}
+		return string(runes), nil
+	}
+	if di.CharsetDecoder != nil {
+		return di.CharsetDecoder(charset, data)
+	}
+	return "", errors.New("vcard: unknown charset " + charset)
+}
+
+// delete the parameter name, matched case-insensitively
+func deleteParam(contentLine *ContentLine, name string) {
+	for key := range contentLine.Params {
+		if strings.EqualFold(key, name) {
+			delete(contentLine.Params, key)
+		}
+	}
+}
+
+// transcode to UTF-8 the values of a content line which are not valid
+// UTF-8 from its CHARSET, or DefaultCharset. quoted-printable values are
+// decoded first, the ENCODING and CHARSET parameters are then removed
+func (di *DirectoryInfoReader) transcode(contentLine *ContentLine) {
+	if binaryProperties[strings.ToUpper(contentLine.Name)] {
+		return
+	}
+	charset := di.DefaultCharset
+	if param, ok := contentLine.Param("CHARSET"); ok && param.GetText() != "" {
+		charset = param.GetText()
+	}
+	encoding, _ := contentLine.Param("ENCODING")
+	quotedPrintable := strings.EqualFold(encoding.GetText(), "QUOTED-PRINTABLE")
+	data := make([][][]byte, len(contentLine.Value))
+	valid := true
+	for i, value := range contentLine.Value {
+		data[i] = make([][]byte, len(value))
+		for j, text := range value {
+			b := unescapeBytes(text)
+			value[j] = string(b)
+			if quotedPrintable {
+				if decoded, err := ioutil.ReadAll(newQuotedPrintableReader(bytes.NewReader(b))); err == nil {
+					b = decoded
+				}
+			}
+			data[i][j] = b
+			valid = valid && utf8.Valid(b)
+		}
+	}
+	if valid {
+		return
+	}
+	for i, value := range contentLine.Value {
+		for j := range value {
+			if utf8.Valid(data[i][j]) {
+				value[j] = string(data[i][j])
+				continue
+			}
+			text, err := di.decodeCharset(charset, data[i][j])
+			if err != nil {
+				di.logf("Not transcoded %s: %s\n", contentLine.Name, err)
+				text = string(data[i][j])
+			} else {
+				di.stats.Transcoded += len(data[i][j])
+			}
+			value[j] = text
+		}
+	}
+	if quotedPrintable {
+		deleteParam(contentLine, "ENCODING")
+	}
+	deleteParam(contentLine, "CHARSET")
+}
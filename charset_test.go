@@ -0,0 +1,72 @@
+package vcard
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// decode the few Shift_JIS characters of the tests
+func decodeShiftJIS(charset string, data []byte) (string, error) {
+	replacer := strings.NewReplacer("\x95\x5C", "表", "\x83\x5C", "ソ", "\x83\x6E", "ハ")
+	s := replacer.Replace(string(data))
+	if !strings.EqualFold(charset, "Shift_JIS") || strings.IndexFunc(s, func(r rune) bool { return r == 0xFFFD }) >= 0 {
+		return "", errors.New("not decoded")
+	}
+	return s, nil
+}
+
+func TestShiftJISTrailByte(t *testing.T) {
+	in := "BEGIN:VCARD\r\nVERSION:2.1\r\nN:\x95\x5C;\x83\x5C\x83\x6E\r\nFN:\x95\x5C \x83\x5C\x83\x6E\r\nNOTE:a\x95\x5Cb\\, c\r\nTITLE;CHARSET=ISO-8859-1:caf\xe9\r\nEND:VCARD\r\n"
+	di := NewDirectoryInfoReader(strings.NewReader(in))
+	di.DefaultCharset = "Shift_JIS"
+	di.CharsetDecoder = decodeShiftJIS
+	v := di.ReadVCard()
+	if len(v.FamilyNames) != 1 || v.FamilyNames[0] != "表" || len(v.GivenNames) != 1 || v.GivenNames[0] != "ソハ" {
+		t.Fatalf("%q %q", v.FamilyNames, v.GivenNames)
+	}
+	if v.FormattedName != "表 ソハ" || v.Note != "a表b, c" || v.Titles[0] != "café" {
+		t.Fatalf("%q %q %q", v.FormattedName, v.Note, v.Titles)
+	}
+}
+
+func TestShiftJISParameter(t *testing.T) {
+	in := "BEGIN:VCARD\r\nVERSION:2.1\r\nN:a\r\nTEL;X-LABEL=\x83\x5C\x83\x6E:1234\r\nNOTE:\uf710\uf7a0\r\nEND:VCARD\r\n"
+	di := NewDirectoryInfoReader(strings.NewReader(in))
+	di.DefaultCharset = "Shift_JIS"
+	di.CharsetDecoder = decodeShiftJIS
+	di.Fidelity = true
+	v := di.ReadVCard()
+	if v.Note != "\uf710\uf7a0" {
+		t.Fatalf("%q", v.Note)
+	}
+	if label, _ := v.Telephones[0].source.Param("X-LABEL"); label.GetText() != "ソハ" {
+		t.Fatalf("%q", label)
+	}
+}
+
+func TestRepairMojibake(t *testing.T) {
+	for _, test := range []struct{ s, want string }{
+		{"JosÃ©", "José"},
+		{"Ã‰milie", "Émilie"},
+		{"â‚¬ 10", "€ 10"},
+		{"æ—¥æœ¬", "日本"},
+		{"José", "José"},
+		{"plain ascii", "plain ascii"},
+		{"Ã© and é", "Ã© and é"},
+		{"Ã€ 日本", "Ã€ 日本"},
+		{"", ""},
+	} {
+		if repaired := RepairMojibake(test.s); repaired != test.want {
+			t.Fatalf("%q: %q", test.s, repaired)
+		}
+	}
+	di := NewDirectoryInfoReader(strings.NewReader("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:JosÃ©\r\nNOTE:CafÃ©\r\nEND:VCARD\r\n"))
+	di.RepairMojibake = true
+	if v := di.ReadVCard(); v.FormattedName != "José" || v.Note != "Café" {
+		t.Fatalf("%+v", v)
+	}
+	if v := readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:JosÃ©\r\nEND:VCARD\r\n"); v.FormattedName != "JosÃ©" {
+		t.Fatal(v.FormattedName)
+	}
+}
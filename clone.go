@@ -0,0 +1,290 @@
+package vcard
+
+import (
+	"strings"
+)
+
+func copyStrings(ss []string) []string {
+	if ss == nil {
+		return nil
+	}
+	return append([]string(nil), ss...)
+}
+
+func copyInts(is []int) []int {
+	if is == nil {
+		return nil
+	}
+	return append([]int(nil), is...)
+}
+
+// return a copy of the content line, nil if nil, e.g: the source of a
+// property read in fidelity mode
+func cloneContentLine(cl *ContentLine) *ContentLine {
+	if cl == nil {
+		return nil
+	}
+	return cl.Clone()
+}
+
+func cloneContentLines(cls []*ContentLine) []*ContentLine {
+	if cls == nil {
+		return nil
+	}
+	c := make([]*ContentLine, len(cls))
+	for i, cl := range cls {
+		c[i] = cl.Clone()
+	}
+	return c
+}
+
+// return a deep copy of the vcard
+func (vcard *VCard) Clone() *VCard {
+	c := *vcard
+	c.Photo.Folds = copyInts(vcard.Photo.Folds)
+	if vcard.Geo != nil {
+		geo := *vcard.Geo
+		c.Geo = &geo
+	}
+	c.FamilyNames = copyStrings(vcard.FamilyNames)
+	c.GivenNames = copyStrings(vcard.GivenNames)
+	c.AdditionalNames = copyStrings(vcard.AdditionalNames)
+	c.HonorificNames = copyStrings(vcard.HonorificNames)
+	c.HonorificSuffixes = copyStrings(vcard.HonorificSuffixes)
+	c.NameExtra = copyStrings(vcard.NameExtra)
+	c.SortAs = copyStrings(vcard.SortAs)
+	c.Alternates = copyNames(vcard.Alternates)
+	c.NickNames = copyStrings(vcard.NickNames)
+	c.Titles = copyStrings(vcard.Titles)
+	c.Roles = copyStrings(vcard.Roles)
+	c.Org = copyStrings(vcard.Org)
+	c.Categories = copyStrings(vcard.Categories)
+	c.Members = copyStrings(vcard.Members)
+	c.Expertises = copyInterests(vcard.Expertises)
+	c.Hobbies = copyInterests(vcard.Hobbies)
+	c.Interests = copyInterests(vcard.Interests)
+	c.OrgDirectories = copyStrings(vcard.OrgDirectories)
+	c.ContactURIs = copyStrings(vcard.ContactURIs)
+	if vcard.Addresses != nil {
+		c.Addresses = make([]Address, len(vcard.Addresses))
+		for i, addr := range vcard.Addresses {
+			addr.Type = copyStrings(addr.Type)
+			addr.Extra = copyStrings(addr.Extra)
+			addr.source = cloneContentLine(addr.source)
+			if addr.Geo != nil {
+				geo := *addr.Geo
+				addr.Geo = &geo
+			}
+			c.Addresses[i] = addr
+		}
+	}
+	if vcard.Telephones != nil {
+		c.Telephones = make([]Telephone, len(vcard.Telephones))
+		for i, tel := range vcard.Telephones {
+			tel.Type = copyStrings(tel.Type)
+			tel.source = cloneContentLine(tel.source)
+			c.Telephones[i] = tel
+		}
+	}
+	if vcard.Emails != nil {
+		c.Emails = make([]Email, len(vcard.Emails))
+		for i, email := range vcard.Emails {
+			email.Type = copyStrings(email.Type)
+			email.source = cloneContentLine(email.source)
+			c.Emails[i] = email
+		}
+	}
+	if vcard.URLs != nil {
+		c.URLs = make([]URL, len(vcard.URLs))
+		for i, url := range vcard.URLs {
+			url.Type = copyStrings(url.Type)
+			url.source = cloneContentLine(url.source)
+			c.URLs[i] = url
+		}
+	}
+	if vcard.XJabbers != nil {
+		c.XJabbers = make([]XJabber, len(vcard.XJabbers))
+		for i, jab := range vcard.XJabbers {
+			jab.Type = copyStrings(jab.Type)
+			jab.source = cloneContentLine(jab.source)
+			c.XJabbers[i] = jab
+		}
+	}
+	if vcard.CustomDates != nil {
+		c.CustomDates = make([]CustomDate, len(vcard.CustomDates))
+		for i, date := range vcard.CustomDates {
+			date.Type = copyStrings(date.Type)
+			c.CustomDates[i] = date
+		}
+	}
+	if vcard.IMPPs != nil {
+		c.IMPPs = make([]IMPP, len(vcard.IMPPs))
+		for i, impp := range vcard.IMPPs {
+			impp.Type = copyStrings(impp.Type)
+			impp.source = cloneContentLine(impp.source)
+			c.IMPPs[i] = impp
+		}
+	}
+	c.SkippedBinary = copyStrings(vcard.SkippedBinary)
+	c.Extras = cloneContentLines(vcard.Extras)
+	if vcard.occurrences != nil {
+		c.occurrences = make(map[string]int, len(vcard.occurrences))
+		for name, n := range vcard.occurrences {
+			c.occurrences[name] = n
+		}
+	}
+	c.deliveryLabels = cloneContentLines(vcard.deliveryLabels)
+	c.defaultPostal = cloneContentLine(vcard.defaultPostal)
+	if vcard.sources != nil {
+		c.sources = make(map[string][]*ContentLine, len(vcard.sources))
+		for name, sources := range vcard.sources {
+			c.sources[name] = cloneContentLines(sources)
+		}
+	}
+	return &c
+}
+
+// return a copy of the vcard containing only the properties named in keep,
+// e.g: Redact("FN", "ORG"). VERSION is always kept, the names also select
+// the matching extra properties.
+func (vcard *VCard) Redact(keep ...string) *VCard {
+	c := vcard.Clone()
+	r := &VCard{Version: c.Version, nameRedacted: true}
+	keepFN := false
+	for _, name := range keep {
+		name = strings.ToUpper(name)
+		if n := c.occurrences[name]; n > 0 {
+			if r.occurrences == nil {
+				r.occurrences = make(map[string]int)
+			}
+			r.occurrences[name] = n
+		}
+		switch name {
+		case "FN":
+			r.FormattedName = c.FormattedName
+			keepFN = true
+		case "N":
+			r.FamilyNames = c.FamilyNames
+			r.GivenNames = c.GivenNames
+			r.AdditionalNames = c.AdditionalNames
+			r.HonorificNames = c.HonorificNames
+			r.HonorificSuffixes = c.HonorificSuffixes
+			r.NameExtra = c.NameExtra
+			r.SortAs = c.SortAs
+			r.nameRedacted = false
+		case "NICKNAME":
+			r.NickNames = c.NickNames
+		case "X-PHONETIC-FIRST-NAME":
+			r.PhoneticGiven = c.PhoneticGiven
+		case "X-PHONETIC-LAST-NAME":
+			r.PhoneticFamily = c.PhoneticFamily
+		case "PHOTO":
+			r.Photo = c.Photo
+		case "BDAY":
+			r.Birthday = c.Birthday
+			r.BirthdayScale = c.BirthdayScale
+		case "ANNIVERSARY":
+			r.Anniversary = c.Anniversary
+			r.AnniversaryScale = c.AnniversaryScale
+		case "ADR":
+			r.Addresses = c.Addresses
+		case "TEL":
+			r.Telephones = c.Telephones
+		case "EMAIL":
+			r.Emails = c.Emails
+		case "TITLE":
+			r.Titles = c.Titles
+		case "ROLE":
+			r.Roles = c.Roles
+		case "ORG":
+			r.Org = c.Org
+		case "CATEGORIES":
+			r.Categories = c.Categories
+		case "NOTE":
+			r.Note = c.Note
+		case "URL":
+			r.URLs = c.URLs
+		case "UID":
+			r.UID = c.UID
+		case "KIND":
+			r.Kind = c.Kind
+		case "MEMBER":
+			r.Members = c.Members
+			r.XAddressBookServer = c.XAddressBookServer
+		case "GEO":
+			r.Geo = c.Geo
+		case "X-JABBER":
+			r.XJabbers = c.XJabbers
+		case "IMPP":
+			r.IMPPs = c.IMPPs
+		case "X-ABDATE":
+			r.CustomDates = c.CustomDates
+		case "EXPERTISE":
+			r.Expertises = c.Expertises
+		case "HOBBY":
+			r.Hobbies = c.Hobbies
+		case "INTEREST":
+			r.Interests = c.Interests
+		case "ORG-DIRECTORY":
+			r.OrgDirectories = c.OrgDirectories
+		case "BIRTHPLACE":
+			r.Birthplace = c.Birthplace
+			r.BirthplaceValue = c.BirthplaceValue
+		case "DEATHPLACE":
+			r.Deathplace = c.Deathplace
+		case "DEATHDATE":
+			r.Deathdate = c.Deathdate
+			r.DeathdateScale = c.DeathdateScale
+		case "CONTACT-URI":
+			r.ContactURIs = c.ContactURIs
+		case "AGENT":
+			r.AgentURI = c.AgentURI
+		case "RELATED":
+			r.AgentURI = c.AgentURI
+		case "X-ABUID":
+			r.XABuid = c.XABuid
+		case "X-ABSHOWAS":
+			r.XABShowAs = c.XABShowAs
+		case "TZ":
+			r.TimeZone = c.TimeZone
+		case "REV":
+			r.Revision = c.Revision
+		}
+		// the extras of a modeled property are kept too, e.g: an embedded
+		// AGENT vcard
+		for _, extra := range c.Extras {
+			if strings.EqualFold(extra.Name, name) {
+				r.Extras = append(r.Extras, extra)
+			}
+		}
+		if c.skippedBinary(name) {
+			r.SkippedBinary = append(r.SkippedBinary, name)
+		}
+	}
+	r.Alternates = redactNames(c.Alternates, keepFN, !r.nameRedacted)
+	return r
+}
+
+// return the alternate names keeping only their FN, their N or both, the
+// names left empty are dropped
+func redactNames(names []Name, keepFN, keepN bool) []Name {
+	var redacted []Name
+	for _, name := range names {
+		if !keepFN {
+			name.FormattedName = ""
+			name.primaryFN = false
+		}
+		if !keepN {
+			name.FamilyNames = nil
+			name.GivenNames = nil
+			name.AdditionalNames = nil
+			name.HonorificNames = nil
+			name.HonorificSuffixes = nil
+			name.primaryN = false
+		}
+		if name.FormattedName != "" || name.primaryFN || name.hasName() || name.primaryN {
+			redacted = append(redacted, name)
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,35 @@
+package vcard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCloneSources(t *testing.T) {
+	di := NewDirectoryInfoReader(strings.NewReader("BEGIN:VCARD\r\nVERSION:3.0\r\nFN;X-A=1:a\r\nN:a;;;;\r\nTEL;type=cell:1234\r\nEMAIL;type=home:a@example.com\r\nEND:VCARD\r\n"))
+	di.Fidelity = true
+	v := di.ReadVCard()
+	var want strings.Builder
+	if err := v.Write(&want); err != nil {
+		t.Fatal(err)
+	}
+	c := v.Clone()
+	c.Telephones[0].source.Params["type"][0] = "work"
+	c.Emails[0].source.Name = "X-EMAIL"
+	c.sources["FN"][0].Params["X-A"] = Value{"2"}
+	c.occurrences["TEL"] = 2
+	var b strings.Builder
+	if err := v.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != want.String() || v.occurrences["TEL"] != 1 {
+		t.Fatal(b.String(), v.occurrences)
+	}
+	b.Reset()
+	if err := c.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "\r\nFN;X-A=2:a\r\n") {
+		t.Fatal(b.String())
+	}
+}
@@ -1,9 +1,18 @@
 package vcard
 
+import (
+	"strings"
+)
+
 type ContentLine struct {
 	Group, Name string
 	Params      map[string]Value
 	Value       StructuredValue
+	// parameter names in read order, only set by a reader in fidelity mode
+	ParamOrder []string
+	// offsets in the escaped value where the content line was folded, only
+	// set by a reader in fidelity mode
+	Folds []int
 }
 
 // values separated by ';' has a structural meaning
@@ -35,3 +44,77 @@ func (v Value) GetText() string {
 	}
 	return ""
 }
+
+// return the component name of a BEGIN or END content line
+// uppercased and without surrounding whitespace, e.g: "VCARD"
+func (cl *ContentLine) ComponentName() string {
+	return strings.ToUpper(strings.TrimSpace(cl.Value.GetText()))
+}
+
+// return the values of the parameter name matched case-insensitively,
+// e.g: Param("TYPE") returns the values of both "TYPE" and "type"
+func (cl *ContentLine) Param(name string) (Value, bool) {
+	var values Value
+	found := false
+	for key, v := range cl.Params {
+		if strings.EqualFold(key, name) {
+			if found {
+				// several casings, e.g: type and TYPE, merged in write order
+				return cl.mergedParam(name), true
+			}
+			values = append(values, v...)
+			found = true
+		}
+	}
+	return values, found
+}
+
+// return the values of the parameters named name case-insensitively, in
+// the order of paramKeys
+func (cl *ContentLine) mergedParam(name string) Value {
+	var values Value
+	for _, key := range paramKeys(cl) {
+		if strings.EqualFold(key, name) {
+			values = append(values, cl.Params[key]...)
+		}
+	}
+	return values
+}
+
+// vCard 2.1 allows parameter values without parameter name,
+// e.g: TEL;CELL;HOME:+1 555 1234
+// move them to the ENCODING parameter for encodings, to TYPE otherwise
+func normalizeBareParameters(cl *ContentLine) {
+	for key, values := range cl.Params {
+		if len(values) != 1 || values[0] != "" {
+			continue
+		}
+		delete(cl.Params, key)
+		name := "TYPE"
+		switch strings.ToUpper(key) {
+		case "QUOTED-PRINTABLE", "BASE64", "8BIT", "7BIT", "BINARY":
+			name = "ENCODING"
+		}
+		cl.Params[name] = append(cl.Params[name], key)
+	}
+}
+
+// return a deep copy of the content line
+func (cl *ContentLine) Clone() *ContentLine {
+	c := *cl
+	if cl.Params != nil {
+		c.Params = make(map[string]Value, len(cl.Params))
+		for key, values := range cl.Params {
+			c.Params[key] = append(Value(nil), values...)
+		}
+	}
+	if cl.Value != nil {
+		c.Value = make(StructuredValue, len(cl.Value))
+		for i, v := range cl.Value {
+			c.Value[i] = append(Value(nil), v...)
+		}
+	}
+	c.ParamOrder = copyStrings(cl.ParamOrder)
+	c.Folds = copyInts(cl.Folds)
+	return &c
+}
@@ -0,0 +1,47 @@
+package vcard
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// a 4.0 date-and-or-time value, reduced precision and truncated forms
+// included, e.g: 1985, 1985-04, --0412 or 19850412T1030Z
+var dateAndOrTime = regexp.MustCompile(`^(\d{4}(-?\d{2}(-?\d{2})?)?|--\d{2}(-?\d{2})?|---\d{2})?(T[\d:.]+(Z|[+-]\d{2}(:?\d{2})?)?)?$`)
+
+// return the birthday as a time, an error if BDAY is not a precise date,
+// e.g: the 19XX or "circa 1800" values of genealogy tools, or a date
+// without year. such values are kept unchanged in Birthday. the time zone
+// of a date-time is kept, the date not being shifted to UTC
+func (vcard *VCard) BirthdayDate() (time.Time, error) {
+	if t, ok := parseTimestamp(vcard.Birthday); ok {
+		return t, nil
+	}
+	return time.Time{}, errors.New("vcard: BDAY " + vcard.Birthday + " is not a precise date")
+}
+
+// return whether the value of a date property is a date, or is declared
+// as text by VALUE=text, e.g: BDAY;VALUE=text:circa 1800
+func isDateValue(contentLine *ContentLine) bool {
+	text := contentLine.Value.GetText()
+	if text == "" || dateAndOrTime.MatchString(text) {
+		return true
+	}
+	value, _ := contentLine.Param("VALUE")
+	return strings.EqualFold(value.GetText(), "text")
+}
+
+// return the parameters of a date property: its calendar scale, and in
+// 4.0 VALUE=text for a value which is not a date, e.g: BDAY;VALUE=text:19XX
+func dateParams(di *DirectoryInfoWriter, calendar, value string) map[string]Value {
+	params := calendarParams(di, calendar)
+	if di.version() == "4.0" && value != "" && !dateAndOrTime.MatchString(value) {
+		if params == nil {
+			params = make(map[string]Value)
+		}
+		params["VALUE"] = Value{"text"}
+	}
+	return params
+}
@@ -0,0 +1,72 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCalendarScaleVersion(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:a\r\nBDAY;CALSCALE=chinese:20200101\r\nEND:VCARD\r\n")
+	for version, want := range map[string]string{"3.0": "\r\nBDAY:20200101\r\n", "4.0": "\r\nBDAY;CALSCALE=chinese:20200101\r\n"} {
+		var b bytes.Buffer
+		di := NewDirectoryInfoWriter(&b)
+		di.Version = version
+		v.WriteTo(di)
+		if !strings.Contains(b.String(), want) {
+			t.Fatal(version, b.String())
+		}
+	}
+}
+
+func TestDeathdate(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:a\r\nDEATHDATE;CALSCALE=chinese:20200101\r\nDEATHPLACE:Rome\r\nEND:VCARD\r\n")
+	if v.Deathdate != "20200101" || v.DeathdateScale != "chinese" {
+		t.Fatalf("%+v", v)
+	}
+	var b bytes.Buffer
+	di := NewDirectoryInfoWriter(&b)
+	di.Version = "3.0"
+	v.WriteTo(di)
+	if !strings.Contains(b.String(), "\r\nDEATHPLACE:Rome\r\nDEATHDATE:20200101\r\n") {
+		t.Fatal(b.String())
+	}
+	b.Reset()
+	di.Version = "4.0"
+	v.Deathdate = "circa 1800"
+	v.WriteTo(di)
+	if !strings.Contains(b.String(), "\r\nDEATHPLACE:Rome\r\nDEATHDATE;CALSCALE=chinese;VALUE=text:circa 1800\r\n") {
+		t.Fatal(b.String())
+	}
+}
+
+func TestBirthdayDateZone(t *testing.T) {
+	for _, bday := range []string{"1985-04-12T23:00:00-05:00", "19850412", "1985-04-12T01:00:00+09:00"} {
+		v := &VCard{Birthday: bday}
+		d, err := v.BirthdayDate()
+		if err != nil || d.Year() != 1985 || d.Month() != 4 || d.Day() != 12 {
+			t.Fatal(bday, d, err)
+		}
+	}
+}
+
+func TestLenientBirthday(t *testing.T) {
+	for _, lenient := range []bool{false, true} {
+		var logger captureLogger
+		di := NewDirectoryInfoReader(strings.NewReader("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nBDAY:19XX\r\nEND:VCARD\r\nBEGIN:VCARD\r\nVERSION:4.0\r\nFN:b\r\nBDAY;VALUE=text:circa 1800\r\nEND:VCARD\r\nBEGIN:VCARD\r\nVERSION:3.0\r\nFN:c\r\nBDAY:1985-04-12\r\nEND:VCARD\r\n"))
+		di.Logger = &logger
+		di.LenientBirthday = lenient
+		v := di.ReadVCard()
+		if v.Birthday != "19XX" {
+			t.Fatal(v.Birthday)
+		}
+		if _, err := v.BirthdayDate(); err == nil {
+			t.Fatal("19XX read as a date")
+		}
+		di.ReadVCard()
+		di.ReadVCard()
+		if lenient && len(logger.messages) != 0 || !lenient && len(logger.messages) != 1 {
+			t.Fatal(lenient, logger.messages)
+		}
+	}
+}
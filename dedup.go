@@ -0,0 +1,39 @@
+package vcard
+
+// group the cards by the key returned by key, e.g: the UID or a normalized
+// email. unique holds one card per key in input order, the first card of a
+// group merged with the others, those Equal to it being skipped, duplicates
+// holds the groups of more than one card. cards with an empty key are never
+// considered duplicates.
+func DeduplicateCards(cards []*VCard, key func(*VCard) string) (unique []*VCard, duplicates [][]*VCard) {
+	groups := make(map[string][]*VCard)
+	index := make(map[string]int) // position of the group in unique
+	for _, card := range cards {
+		k := key(card)
+		if k == "" {
+			unique = append(unique, card)
+			continue
+		}
+		if _, ok := groups[k]; !ok {
+			index[k] = len(unique)
+			unique = append(unique, card)
+		}
+		groups[k] = append(groups[k], card)
+	}
+	for i, card := range unique {
+		k := key(card)
+		group := groups[k]
+		if len(group) < 2 || index[k] != i {
+			continue
+		}
+		merged := group[0].Clone()
+		for _, card := range group[1:] {
+			if !card.Equal(group[0]) {
+				merged.Merge(card)
+			}
+		}
+		unique[i] = merged
+		duplicates = append(duplicates, group)
+	}
+	return unique, duplicates
+}
@@ -0,0 +1,43 @@
+package vcard
+
+import (
+	"testing"
+)
+
+func TestDeduplicateCards(t *testing.T) {
+	a1 := &VCard{FormattedName: "a", UID: "1", Emails: []Email{{Address: "a@example.com"}}}
+	b := &VCard{FormattedName: "b", UID: "2"}
+	a2 := &VCard{FormattedName: "a", UID: "1", Telephones: []Telephone{{Number: "+1 555 0100"}}}
+	c1 := &VCard{FormattedName: "c"}
+	c2 := &VCard{FormattedName: "c"}
+	unique, duplicates := DeduplicateCards([]*VCard{a1, b, a2, c1, c2}, func(v *VCard) string { return v.UID })
+	if len(unique) != 4 || unique[1] != b || unique[2] != c1 || unique[3] != c2 {
+		t.Fatalf("%+v", unique)
+	}
+	merged := unique[0]
+	if merged == a1 || merged.PrimaryEmail() != "a@example.com" || merged.PrimaryPhone() != "+1 555 0100" {
+		t.Fatalf("%+v", merged)
+	}
+	// the cards are left unchanged
+	if len(a1.Telephones) != 0 || len(a2.Emails) != 0 {
+		t.Fatalf("%+v %+v", a1, a2)
+	}
+	if len(duplicates) != 1 || len(duplicates[0]) != 2 || duplicates[0][0] != a1 || duplicates[0][1] != a2 {
+		t.Fatalf("%+v", duplicates)
+	}
+	if unique, duplicates := DeduplicateCards(nil, func(v *VCard) string { return v.UID }); unique != nil || duplicates != nil {
+		t.Fatal(unique, duplicates)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a := readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nN:a;;;;\r\nTEL:1\r\nEMAIL:a@example.com\r\nREV:2020-01-01T00:00:00Z\r\nEND:VCARD\r\n")
+	b := readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nN:a;;;;\r\nEMAIL:a@example.com\r\nTEL:1\r\nREV:2021-01-01T00:00:00Z\r\nEND:VCARD\r\n")
+	if !a.Equal(b) || !a.Equal(a.Clone()) {
+		t.Fatal("equal cards differ")
+	}
+	b.Note = "b"
+	if a.Equal(b) {
+		t.Fatal("different cards are equal")
+	}
+}
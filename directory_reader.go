@@ -3,47 +3,275 @@ package vcard
 
 import (
 	"io"
+	"net/url"
+	"strings"
 	"text/scanner"
+	"unicode/utf8"
 )
 
+// record the first error of the underlying reader, the scanner handles
+// errors as end of input
+type errorReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (er *errorReader) Read(p []byte) (int, error) {
+	n, err := er.reader.Read(p)
+	if err != nil && err != io.EOF && er.err == nil {
+		er.err = err
+	}
+	return n, err
+}
+
+// the logger of the reader and writer diagnostics, e.g: a *log.Logger
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// counters of the content read by a DirectoryInfoReader
+type ParseStats struct {
+	Cards           int // vcards read
+	Skipped         int // properties not read, kept in the extras or ignored
+	QuotedPrintable int // content lines encoded in quoted-printable
+	Transcoded      int // bytes transcoded from AssumeCharset or DefaultCharset to UTF-8
+	Warnings        int // diagnostics passed to the Logger, if any
+}
+
 type DirectoryInfoReader struct {
 	scan *scanner.Scanner
+	er   *errorReader
+	buf  []byte // reused between reads to limit allocations
+	// start of the next content line, read by LenientLineBreaks
+	pending []byte
+	// populate an empty N from FN using ParseName
+	NameFromFN bool
+	// fidelity mode: keep the parameter order and casing of the content lines
+	// and where their values were folded so they survive a round trip. the
+	// parameters of a modeled property are matched by occurrence, e.g: the
+	// second TITLE written takes them from the second TITLE read, and the
+	// decoded ENCODING and CHARSET are not kept
+	Fidelity bool
+	// merge consecutive vcards sharing the same UID
+	CoalesceUID bool
+	// charset of the bytes which are not valid UTF-8, e.g: windows-1252 for
+	// Outlook notes, they are transcoded to UTF-8. windows-1252, iso-8859-1
+	// and iso-8859-15 are supported
+	AssumeCharset string
+	// charset of the values of the properties without CHARSET parameter,
+	// e.g: Shift_JIS for the 2.1 exports of Japanese phones declaring it
+	// once for the file. the values which are not valid UTF-8, raw or
+	// quoted-printable, are transcoded from their CHARSET or DefaultCharset,
+	// the parameter values from DefaultCharset. the trail bytes of a
+	// double-byte DefaultCharset like Shift_JIS are not taken as a backslash
+	// or a separator. ignored for the bytes transcoded by AssumeCharset
+	DefaultCharset string
+	// decoder of the charsets unknown to the reader, e.g: Shift_JIS decoded
+	// with golang.org/x/text/encoding/japanese
+	CharsetDecoder func(charset string, data []byte) (string, error)
+	// discard the inline data of PHOTO, LOGO, SOUND and KEY, their parameters
+	// are kept and Photo.Skipped or VCard.SkippedBinary is set, e.g: to index
+	// metadata only
+	SkipBinaryData bool
+	// repair the text values double encoded in UTF-8, e.g: "Ã©" read as "é",
+	// see RepairMojibake
+	RepairMojibake bool
+	// read the comma separated values of a single valued TEL or EMAIL as
+	// several telephones or emails, e.g: EMAIL:a@example.com,b@example.com
+	// as written by some CRM exports. only the first value is read otherwise
+	SplitMultiValue bool
+	// read a line which doesn't start with a property name followed by ':'
+	// or ';' as the continuation of the value of the previous line, e.g: an
+	// unescaped line break in a NOTE written by a buggy tool
+	LenientLineBreaks bool
+	// decode the percent-encoded parameter values, e.g: LABEL=123%20Main
+	// written by some CardDAV servers. the values which are not valid
+	// percent-encoding are read as is, as are all values by default since
+	// a '%' may be literal, e.g: in a URL
+	DecodePercent bool
+	// read the content lines found outside any component as a vcard missing
+	// its BEGIN:VCARD, e.g: a snippet pasted from an email starting with FN.
+	// the vcard ends at END:VCARD, the next BEGIN:VCARD or the end of input
+	ImplicitBegin bool
+	// keep the BDAY values which are not a date without logging them, e.g:
+	// the 19XX or "circa 1800" of genealogy tools, see BirthdayDate
+	LenientBirthday bool
+	// logger of the properties not read and the invalid values, nothing is
+	// logged if nil
+	Logger Logger
+	// handlers called on each content line of a vcard before default handling
+	propertyHandlers []func(*ContentLine, *VCard) bool
+	depth            int // nesting level in non VCARD components
+	stats            ParseStats
+	unread           *ContentLine // BEGIN line of the vcard following a malformed one
+	implicit         bool         // reading a vcard without BEGIN, see ImplicitBegin
+	errs             []error      // malformed vcards
 }
 
 func NewDirectoryInfoReader(reader io.Reader) *DirectoryInfoReader {
 	var s scanner.Scanner
-	s.Init(reader)
-	return &DirectoryInfoReader{&s}
+	er := &errorReader{reader: reader}
+	di := &DirectoryInfoReader{scan: &s, er: er}
+	s.Init(&charsetReader{reader: er, charset: &di.AssumeCharset, escape: &di.DefaultCharset, logf: di.logf, transcoded: &di.stats.Transcoded})
+	return di
+}
+
+// return the values of a single valued content line: the first one, or all
+// the values of the first component with SplitMultiValue
+func (di *DirectoryInfoReader) values(contentLine *ContentLine) []string {
+	if di.SplitMultiValue && len(contentLine.Value) > 0 && len(contentLine.Value[0]) > 1 {
+		return contentLine.Value[0]
+	}
+	return []string{contentLine.Value.GetText()}
+}
+
+// return the errors of the malformed vcards read, e.g: a vcard without END,
+// the vcards following them are read nevertheless
+func (di *DirectoryInfoReader) Errors() []error {
+	return di.errs
+}
+
+// return the counters of the content read so far
+func (di *DirectoryInfoReader) Stats() ParseStats {
+	return di.stats
+}
+
+// log the message with the Logger of the reader, if any
+func (di *DirectoryInfoReader) logf(format string, v ...interface{}) {
+	di.stats.Warnings++
+	if di.Logger != nil {
+		di.Logger.Printf(format, v...)
+	}
+}
+
+// return the first error returned by the underlying reader, if any
+func (di *DirectoryInfoReader) Err() error {
+	return di.er.err
+}
+
+// register a handler called by VCard.ReadFrom for each content line before
+// the default handling, the handler returns true if it handled the line
+func (di *DirectoryInfoReader) OnProperty(handler func(cl *ContentLine, vcard *VCard) bool) {
+	di.propertyHandlers = append(di.propertyHandlers, handler)
+}
+
+// register a handler called by VCard.ReadFrom instead of the default
+// handling for the content lines named name, compared case-insensitively,
+// e.g: Handle("X-FOO", ...)
+func (di *DirectoryInfoReader) Handle(name string, handler func(cl *ContentLine, vcard *VCard)) {
+	di.OnProperty(func(cl *ContentLine, vcard *VCard) bool {
+		if !strings.EqualFold(cl.Name, name) {
+			return false
+		}
+		handler(cl, vcard)
+		return true
+	})
+}
+
+// call the registered property handlers until one handle the content line
+func (di *DirectoryInfoReader) handleProperty(cl *ContentLine, vcard *VCard) bool {
+	for _, handler := range di.propertyHandlers {
+		if handler(cl, vcard) {
+			return true
+		}
+	}
+	return false
 }
 
 func (di *DirectoryInfoReader) ReadContentLine() *ContentLine {
+	if contentLine := di.unread; contentLine != nil {
+		di.unread = nil
+		return contentLine
+	}
 	if di.scan.Peek() == scanner.EOF {
 		return nil
 	}
 	group, name := di.readGroupName()
-	params := make(map[string]Value)
+	// most content lines have no parameter, don't allocate a map for them
+	var params map[string]Value
+	var order []string
 	if di.scan.Peek() == ';' {
-		params = di.readParameters()
+		params, order = di.readParameters()
+		if di.scan.Peek() != ':' {
+			// the line ended before the value, e.g: a corrupted line
+			di.stats.Skipped++
+			di.logf("Malformed line: %s\n", name)
+			return di.ReadContentLine()
+		}
 	}
 	di.scan.Next()
-	value := di.readValues()
-	return &ContentLine{group, name, params, value}
+	if di.SkipBinaryData && binaryProperties[strings.ToUpper(name)] && !isURIValue(params) {
+		di.skipValues()
+		return &ContentLine{group, name, params, StructuredValue{}, order, nil}
+	}
+	value, folds := di.readValues()
+	return &ContentLine{group, name, params, value, order, folds}
+}
+
+// properties whose value may be inline binary data
+var binaryProperties = map[string]bool{
+	"PHOTO": true, "LOGO": true, "SOUND": true, "KEY": true,
+}
+
+// return true if the VALUE parameter is uri or url, e.g: PHOTO;VALUE=uri:http://...
+func isURIValue(params map[string]Value) bool {
+	for key, value := range params {
+		if strings.EqualFold(key, "VALUE") {
+			text := value.GetText()
+			return strings.EqualFold(text, "uri") || strings.EqualFold(text, "url")
+		}
+	}
+	return false
+}
+
+// skip a value, including its folded lines, without reading it
+func (di *DirectoryInfoReader) skipValues() {
+	for c := di.scan.Next(); c != scanner.EOF; c = di.scan.Next() {
+		if c == '\n' {
+			if la := di.scan.Peek(); la != ' ' && la != '\t' {
+				return
+			}
+		}
+	}
 }
 
 func (di *DirectoryInfoReader) readGroupName() (group, name string) {
 	c := di.scan.Peek()
-	var buf []rune
+	di.buf = di.buf[:0]
+	for _, p := range di.pending {
+		if p == '.' {
+			group = strings.TrimSpace(string(di.buf))
+			di.buf = di.buf[:0]
+		} else {
+			di.buf = append(di.buf, p)
+		}
+	}
+	di.pending = di.pending[:0]
 	for c != scanner.EOF {
 		if c == '.' {
-			group = string(buf)
-			buf = []rune{}
+			group = strings.TrimSpace(string(di.buf))
+			di.buf = di.buf[:0]
 		} else if c == ';' || c == ':' {
-			name = string(buf)
+			name = strings.TrimSpace(string(di.buf))
 			return
 		} else if c == '\n' || c == '\r' {
 			// skip empty line in vcard
+			di.scan.Next()
+			next := di.scan.Peek()
+			if next == ' ' || next == '\t' {
+				// folded name
+				di.scan.Next()
+			} else if (c == '\n' || next != '\n') && (strings.TrimSpace(string(di.buf)) != "" || group != "") {
+				// a line without ':', e.g: a corrupted one, the next line
+				// being read as the next content line
+				di.stats.Skipped++
+				di.logf("Malformed line: %s\n", string(di.buf))
+				group, di.buf = "", di.buf[:0]
+			}
+			c = di.scan.Peek()
+			continue
 		} else {
-			buf = append(buf, c)
+			di.buf = utf8.AppendRune(di.buf, c)
 		}
 		di.scan.Next()
 		c = di.scan.Peek()
@@ -51,23 +279,40 @@ func (di *DirectoryInfoReader) readGroupName() (group, name string) {
 	return
 }
 
-func (di *DirectoryInfoReader) readParameters() (params map[string]Value) {
+// read the parameters of a content line, in fidelity mode the parameter
+// names are also returned in read order
+func (di *DirectoryInfoReader) readParameters() (params map[string]Value, order []string) {
 	lastChar := di.scan.Peek()
 	c := lastChar
-	var buf []rune
+	di.buf = di.buf[:0]
 	var name string
 	var value string
 	params = make(map[string]Value)
 	var values Value
+	quoted := false
 	for c != scanner.EOF {
-		if c == ',' {
-			values = append(values, string(buf))
-			buf = []rune{}
+		if c == '\r' || c == '\n' {
+			di.scan.Next()
+			if c == '\r' && di.scan.Peek() == '\n' {
+				di.scan.Next()
+			}
+			if c = di.scan.Peek(); c != ' ' && c != '\t' {
+				// not folded, the line has no value
+				return
+			}
+		} else if c == '"' {
+			// a quoted value may contain ',' ';' and ':'
+			quoted = !quoted
+		} else if quoted {
+			di.buf = utf8.AppendRune(di.buf, c)
+		} else if c == ',' {
+			values = append(values, di.paramValue(string(di.buf)))
+			di.buf = di.buf[:0]
 		} else if c == ';' || c == ':' {
 			if name == "" {
-				name = string(buf)
+				name = string(di.buf)
 			} else {
-				value = string(buf)
+				value = di.paramValue(string(di.buf))
 			}
 			if name != "" {
 				values = append(values, value)
@@ -75,20 +320,23 @@ func (di *DirectoryInfoReader) readParameters() (params map[string]Value) {
 					params[name] = append(params[name], values...)
 				} else {
 					params[name] = values
+					if di.Fidelity {
+						order = append(order, name)
+					}
 				}
 			}
 			if c == ':' {
 				return
 			}
-			buf = []rune{}
+			di.buf = di.buf[:0]
 			values = Value{}
 			name = ""
 			value = ""
 		} else if c == '=' {
-			name = string(buf)
-			buf = []rune{}
+			name = string(di.buf)
+			di.buf = di.buf[:0]
 		} else {
-			buf = append(buf, c)
+			di.buf = utf8.AppendRune(di.buf, c)
 		}
 		di.scan.Next()
 		c = di.scan.Peek()
@@ -96,24 +344,81 @@ func (di *DirectoryInfoReader) readParameters() (params map[string]Value) {
 	return
 }
 
-func (di *DirectoryInfoReader) readValues() (value StructuredValue) {
+// return a parameter value read, percent-decoded with DecodePercent
+func (di *DirectoryInfoReader) paramValue(text string) string {
+	text = di.unescapeParam(text)
+	if di.DecodePercent {
+		text = decodePercent(text)
+	}
+	return text
+}
+
+// decode a percent-encoded parameter value, e.g: 123%20Main, a value which
+// is not valid percent-encoding is returned unchanged
+func decodePercent(value string) string {
+	if !strings.Contains(value, "%") {
+		return value
+	}
+	if decoded, err := url.PathUnescape(value); err == nil {
+		return decoded
+	}
+	return value
+}
+
+// return true if a character may be part of a property or group name
+func isNameChar(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.'
+}
+
+// read the name characters starting the next line into pending, return
+// true if the line continues the current value: the name characters are
+// not followed by ':' or ';'. an empty line ends the value
+func (di *DirectoryInfoReader) continuesValue() bool {
+	di.pending = di.pending[:0]
+	c := di.scan.Peek()
+	for isNameChar(c) {
+		di.pending = append(di.pending, byte(di.scan.Next()))
+		c = di.scan.Peek()
+	}
+	if c == ':' || c == ';' {
+		return false
+	}
+	return len(di.pending) > 0 || (c != '\r' && c != '\n' && c != scanner.EOF)
+}
+
+// read the values of a content line, and in fidelity mode the offsets in
+// the escaped value of its folds
+func (di *DirectoryInfoReader) readValues() (value StructuredValue, folds []int) {
 	lastChar := di.scan.Next()
 	c := lastChar
-	var buf []rune
+	di.buf = di.buf[:0]
 	escape := false
 	var val Value
+	offset := 0 // length of the escaped value read
 	for c != scanner.EOF {
 		if c == '\n' {
 			la := di.scan.Peek()
 			if la != 32 && la != 9 {
+				if di.LenientLineBreaks && la != scanner.EOF && di.continuesValue() {
+					// unescaped line break, the value isn't written as read
+					di.buf = append(di.buf, '\n')
+					di.buf = append(di.buf, di.pending...)
+					di.pending = di.pending[:0]
+					folds, offset = nil, -1
+					c = di.scan.Next()
+					continue
+				}
 				// return
-				if len(buf) > 0 {
-					val = append(val, string(buf))
+				if len(di.buf) > 0 {
+					val = append(val, string(di.buf))
 				}
 				value = append(value, val)
 				return
 			} else {
 				// unfold
+				if di.Fidelity && offset >= 0 {
+					folds = append(folds, offset)
+				}
 				lastChar = la
 				c = di.scan.Next()
 				for c == 32 || c == 9 {
@@ -121,31 +426,40 @@ func (di *DirectoryInfoReader) readValues() (value StructuredValue) {
 				}
 			}
 		}
-		if c == '\\' {
-			escape = true
-		} else if escape {
+		if offset >= 0 && c != '\n' && c != '\r' && c != scanner.EOF {
+			offset += utf8.RuneLen(c)
+		}
+		if escape {
+			// unescape "\n", "\N", "\\", "\;" and "\,"
 			if c == 'n' || c == 'N' {
 				c = '\n'
 			}
-			buf = append(buf, c)
+			di.buf = utf8.AppendRune(di.buf, c)
 			escape = false
+		} else if c == '\\' {
+			escape = true
 		} else if c == ',' {
-			if len(buf) > 0 {
-				val = append(val, string(buf))
-				buf = []rune{}
+			if len(di.buf) > 0 {
+				val = append(val, string(di.buf))
+				di.buf = di.buf[:0]
 			}
 		} else if c == ';' {
-			if len(buf) > 0 {
-				val = append(val, string(buf))
-				buf = []rune{}
+			if len(di.buf) > 0 {
+				val = append(val, string(di.buf))
+				di.buf = di.buf[:0]
 			}
 			value = append(value, val)
 			val = Value{}
 		} else if c != '\n' && c != '\r' {
-			buf = append(buf, c)
+			di.buf = utf8.AppendRune(di.buf, c)
 		}
 		lastChar = c
 		c = di.scan.Next()
 	}
+	// the last line of the input has no line break, e.g: a pasted snippet
+	if len(di.buf) > 0 {
+		val = append(val, string(di.buf))
+	}
+	value = append(value, val)
 	return
 }
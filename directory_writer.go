@@ -1,82 +1,384 @@
 package vcard
 
 import (
+	"errors"
 	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// maximum line length in octets, excluding the line break
+const maxLineOctets = 75
+
+// how the writer handles a vcard without FN, e.g: an organization card
+type EmptyFNStrategy int
+
+const (
+	EmptyString   EmptyFNStrategy = iota // write an empty FN
+	DeriveFromOrg                        // write the first ORG component as FN
+	EmptyFNError                         // don't write the vcard, Err returning the reason
 )
 
 // Permit to serialize Directory Information data as defined by RFC 2425
 type DirectoryInfoWriter struct {
 	writer io.Writer
+	err    error // first write error
+	// if set, content lines are passed to collect instead of being written
+	collect func(*ContentLine)
+	// vCard version to write, default is the version of the vcard written
+	// if 3.0 or 4.0, e.g: one converted by ConvertTo, 3.0 otherwise
+	Version string
+	// write telephones and emails by preference rather than slice order
+	SortByPreference bool
+	// generate an urn:uuid: UID for cards without one, the UID is set on the card
+	GenerateUID bool
+	// set the revision of each card to the current time, the REV read
+	// is written otherwise
+	StampRevision bool
+	// if set, inline photos larger than PhotoThreshold bytes are passed to
+	// ExternalizePhoto, which stores the data and returns its URL, and the
+	// photo is written as an URI
+	ExternalizePhoto func(data []byte, mediaType string) (url string, err error)
+	PhotoThreshold   int
+	// strict RFC compliance: uppercase property and parameter names, no
+	// empty property, and vcards missing a required property are not
+	// written, Err returning the reason
+	Strict bool
+	// write a blank line between vcards, expected by some importers like
+	// Outlook but rejected by others like iCloud, none by default
+	SeparatorBlankLine bool
+	// X- properties written, extra or modeled like X-ABUID, compared
+	// case-insensitively. all are written if nil, none if empty
+	IncludeXProperties []string
+	// X- properties not written, e.g: X-MS-OL-DESIGN
+	ExcludeXProperties []string
+	// fold the content lines read in fidelity mode where they were folded,
+	// e.g: to keep the base64 wrapping of a signed card. lines are folded
+	// at 75 octets otherwise, or if their value no longer allows it
+	PreserveFolding bool
+	// percent-encode the parameter values which would be altered otherwise,
+	// e.g: containing a double quote, for the readers decoding them, see
+	// DirectoryInfoReader.DecodePercent
+	EncodePercent bool
+	// logger of the diagnostics, e.g: a photo not externalized
+	Logger Logger
+	// handling of the vcards without FN, an empty FN is written by default
+	EmptyFNStrategy EmptyFNStrategy
+	cards           int    // vcards written
+	cardVersion     string // version of the vcard being written
+	// content lines read in fidelity mode of the vcard being written, and
+	// count of the properties written by name, see restoreParams
+	sources map[string][]*ContentLine
+	written map[string]int
+	// lowest PREF of the telephones, emails and IMPPs of the vcard being
+	// written, by property name
+	lowestPref map[string]int
+	// quirks set by WriteProfile
+	upperCase     bool                   // uppercase property and parameter names
+	charsetUTF8   bool                   // add CHARSET=UTF-8 to non ASCII values
+	photoEncoding string                 // ENCODING of inline photos, e.g: b or BASE64
+	skipProperty  func(name string) bool // properties not written
+	appleLabels   bool                   // label the URLs typed homepage
 }
 
-//create a new DirectoryInfoWriter
+// create a new DirectoryInfoWriter
 func NewDirectoryInfoWriter(writer io.Writer) *DirectoryInfoWriter {
-	return &DirectoryInfoWriter{writer}
+	return &DirectoryInfoWriter{writer: writer}
+}
+
+// return the first error encountered while writing, if any
+func (di *DirectoryInfoWriter) Err() error {
+	return di.err
+}
+
+// return the vCard version to write
+func (di *DirectoryInfoWriter) version() string {
+	if di.Version == "" {
+		if di.cardVersion == "4.0" {
+			return "4.0"
+		}
+		return "3.0"
+	}
+	return di.Version
+}
+
+func (di *DirectoryInfoWriter) writeString(s string) {
+	if di.err != nil {
+		return
+	}
+	_, di.err = io.WriteString(di.writer, s)
+}
+
+// in strict mode, return an error if the vcard misses a required property
+func (di *DirectoryInfoWriter) checkRequired(vcard *VCard) error {
+	if di.formattedName(vcard) == "" && (di.Strict || di.EmptyFNStrategy == EmptyFNError) {
+		return errors.New("vcard: missing FN")
+	}
+	if !di.Strict {
+		return nil
+	}
+	// properties read several times are written once, only the name matters
+	if errs := vcard.validateName(di.version()); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// return the FN to write, following EmptyFNStrategy for the vcards without
+func (di *DirectoryInfoWriter) formattedName(vcard *VCard) string {
+	if vcard.FormattedName == "" && di.EmptyFNStrategy == DeriveFromOrg && len(vcard.Org) > 0 {
+		return vcard.Org[0]
+	}
+	return vcard.FormattedName
+}
+
+// return true if name is an X- property filtered out by the
+// IncludeXProperties and ExcludeXProperties options
+func (di *DirectoryInfoWriter) skipXProperty(name string) bool {
+	if len(name) < 2 || !strings.EqualFold(name[:2], "X-") {
+		return false
+	}
+	has := func(names []string) bool {
+		for _, n := range names {
+			if strings.EqualFold(n, name) {
+				return true
+			}
+		}
+		return false
+	}
+	return (di.IncludeXProperties != nil && !has(di.IncludeXProperties)) || has(di.ExcludeXProperties)
+}
+
+// return true if the content line has no value at all
+func isEmptyValue(value StructuredValue) bool {
+	for _, v := range value {
+		for _, s := range v {
+			if s != "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// return true if the content line value is ASCII only
+func isASCII(value StructuredValue) bool {
+	for _, v := range value {
+		for _, s := range v {
+			for i := 0; i < len(s); i++ {
+				if s[i] >= utf8.RuneSelf {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// properties written even if empty, their components being required
+var requiredProperties = map[string]bool{
+	"FN": true, "N": true, "ADR": true,
+}
+
+// log the message with the Logger of the writer, if any
+func (di *DirectoryInfoWriter) logf(format string, v ...interface{}) {
+	if di.Logger != nil {
+		di.Logger.Printf(format, v...)
+	}
 }
 
 func (di *DirectoryInfoWriter) WriteContentLine(contentLine *ContentLine) {
+	if di.sources != nil {
+		contentLine = di.restoreParams(contentLine)
+	}
+	if di.collect != nil {
+		di.collect(contentLine)
+		return
+	}
+	name := contentLine.Name
+	if di.skipProperty != nil && di.skipProperty(name) {
+		return
+	}
+	if di.skipXProperty(name) {
+		return
+	}
+	if di.Strict || di.upperCase {
+		name = strings.ToUpper(name)
+	}
+	if di.Strict && isEmptyValue(contentLine.Value) && !requiredProperties[name] {
+		return
+	}
+	var line strings.Builder
 	if contentLine.Group != "" {
-		io.WriteString(di.writer, contentLine.Group)
-		io.WriteString(di.writer, ".")
+		line.WriteString(contentLine.Group)
+		line.WriteString(".")
+	}
+	line.WriteString(name)
+	if di.charsetUTF8 && !isASCII(contentLine.Value) {
+		if _, ok := contentLine.Param("CHARSET"); !ok {
+			line.WriteString(";CHARSET=UTF-8")
+		}
 	}
-	io.WriteString(di.writer, contentLine.Name)
 	if contentLine.Params != nil {
-		for key, values := range contentLine.Params {
-			io.WriteString(di.writer, ";")
-			io.WriteString(di.writer, key)
+		for _, key := range paramKeys(contentLine) {
+			values := contentLine.Params[key]
+			line.WriteString(";")
+			if di.Strict || di.upperCase {
+				key = strings.ToUpper(key)
+			}
+			line.WriteString(key)
 			if len(values) > 0 {
-				io.WriteString(di.writer, "=")
+				line.WriteString("=")
 				for vi := 0; vi < len(values); vi++ {
-					io.WriteString(di.writer, values[vi])
+					line.WriteString(quoteParamValue(values[vi], di.EncodePercent))
 					if vi+1 < len(values) {
-						io.WriteString(di.writer, ",")
+						line.WriteString(",")
 					}
 				}
 			}
 		}
 	}
-	io.WriteString(di.writer, ":")
+	line.WriteString(":")
+	valueStart := line.Len()
 	for si := 0; si < len(contentLine.Value); si++ {
 		for vi := 0; vi < len(contentLine.Value[si]); vi++ {
-			di.WriteValue(contentLine.Value[si][vi])
+			line.WriteString(escapeValue(contentLine.Value[si][vi]))
 			if vi+1 < len(contentLine.Value[si]) {
-				io.WriteString(di.writer, ",")
+				line.WriteString(",")
 			}
 		}
 		if si+1 < len(contentLine.Value) {
-			io.WriteString(di.writer, ";")
+			line.WriteString(";")
 		}
 	}
-	io.WriteString(di.writer, "\r\n")
+	// if line too long fold it on multiple lines
+	lines := FoldLine(line.String(), maxLineOctets)
+	if di.PreserveFolding && len(contentLine.Folds) > 0 {
+		if folded, ok := foldAt(line.String(), valueStart, contentLine.Folds); ok {
+			lines = folded
+		}
+	}
+	di.writeString(strings.Join(lines, "\r\n "))
+	di.writeString("\r\n")
 }
 
-// this function escape '\n' '\r' ';' ',' character with the '\\' character
-func (di *DirectoryInfoWriter) WriteValue(value string) {
-	i := 0
-	for _, c := range value {
-		if i == 76 {
-			// if line to long fold value on multiple line
-			io.WriteString(di.writer, "\n  ")
-			i = 0
+// split a content line in lines of at most octets bytes as the writer
+// folds it, e.g: FoldLine(line, 75). the continuation lines are
+// prefixed by a space when written. a multi-byte UTF-8 character is
+// never split and continuation lines don't start with whitespace, which
+// the reader would drop when unfolding. for octets less than 1 the line
+// is returned unfolded
+func FoldLine(s string, octets int) []string {
+	if octets < 1 {
+		return []string{s}
+	}
+	var lines []string
+	max := octets
+	for len(s) > max {
+		i := runeStart(s, max)
+		for j := i; j > 0 && (s[j] == ' ' || s[j] == '\t'); {
+			j = runeStart(s, j-1)
+			if s[j] != ' ' && s[j] != '\t' {
+				i = j
+			}
+		}
+		if i == 0 {
+			break
+		}
+		lines = append(lines, s[:i])
+		s = s[i:]
+		max = octets - 1 // room for the leading space
+	}
+	return append(lines, s)
+}
+
+// split a content line at the offsets of its value, false if an offset is
+// out of the value or the line can't be folded there: it splits a UTF-8
+// character or the continuation line would start with whitespace
+func foldAt(s string, valueStart int, folds []int) ([]string, bool) {
+	var lines []string
+	start := 0
+	for _, fold := range folds {
+		i := valueStart + fold
+		if i <= start || i >= len(s) || !utf8.RuneStart(s[i]) || s[i] == ' ' || s[i] == '\t' {
+			return nil, false
 		}
-		var e string
+		lines = append(lines, s[start:i])
+		start = i
+	}
+	return append(lines, s[start:]), true
+}
+
+// return the index of the first byte of the UTF-8 character containing s[i]
+func runeStart(s string, i int) int {
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
+
+// return the parameter names of a content line in ParamOrder order,
+// followed by the other parameter names sorted
+func paramKeys(contentLine *ContentLine) []string {
+	keys := make([]string, 0, len(contentLine.Params))
+	written := make(map[string]bool, len(contentLine.ParamOrder))
+	for _, key := range contentLine.ParamOrder {
+		if _, ok := contentLine.Params[key]; ok && !written[key] {
+			keys = append(keys, key)
+			written[key] = true
+		}
+	}
+	var others []string
+	for key := range contentLine.Params {
+		if !written[key] {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	return append(keys, others...)
+}
+
+// percent-encode the characters a parameter value can't contain
+var percentEncoder = strings.NewReplacer("%", "%25", `"`, "%22", "\r", "%0D", "\n", "%0A")
+
+// parameter values containing ':' ';' or ',' are double quoted. with
+// percent, a double quote or line break is percent-encoded, as is '%' when
+// the value would otherwise be decoded by the reader. a double quote can't
+// be escaped and is dropped otherwise
+func quoteParamValue(value string, percent bool) string {
+	if percent && (strings.ContainsAny(value, "\"\r\n") || decodePercent(value) != value) {
+		value = percentEncoder.Replace(value)
+	}
+	if !strings.ContainsAny(value, `:;,"`) {
+		return value
+	}
+	return `"` + strings.Replace(value, `"`, "", -1) + `"`
+}
+
+// this function escape '\\' '\n' '\r' ';' ',' character with the '\\' character
+// a CRLF or lone '\r' line break is written as "\n"
+func escapeValue(value string) string {
+	value = strings.Replace(value, "\r\n", "\n", -1)
+	var b strings.Builder
+	for _, c := range value {
 		switch c {
-		case '\r':
-			e = `\r`
-		case '\n':
-			e = `\n`
+		case '\\':
+			b.WriteString(`\\`)
+		case '\r', '\n':
+			b.WriteString(`\n`)
 		case ';':
-			e = `\;`
-		case ':':
-			e = `\:`
+			b.WriteString(`\;`)
 		case ',':
-			e = `\,`
+			b.WriteString(`\,`)
 		default:
-			// c is an int representing a Unicode code point.
-			// convert it to string (UTF-8 encoded character)
-			e = string(c)
+			b.WriteRune(c)
 		}
-		io.WriteString(di.writer, e)
-		i++
 	}
+	return b.String()
+}
+
+// write value escaped, see escapeValue
+func (di *DirectoryInfoWriter) WriteValue(value string) {
+	di.writeString(escapeValue(value))
 }
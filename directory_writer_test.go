@@ -0,0 +1,38 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStrict(t *testing.T) {
+	write := func(v *VCard, version string) (string, error) {
+		var b bytes.Buffer
+		di := NewDirectoryInfoWriter(&b)
+		di.Strict = true
+		di.Version = version
+		v.WriteTo(di)
+		return b.String(), di.Err()
+	}
+	for _, test := range []struct {
+		v       *VCard
+		version string
+	}{
+		{&VCard{GivenNames: []string{"a"}}, "3.0"},
+		{&VCard{FormattedName: "a"}, "3.0"},
+	} {
+		if out, err := write(test.v, test.version); err == nil || out != "" {
+			t.Fatalf("%+v %s: %q", test.v, test.version, out)
+		}
+	}
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nN:;a;;;\r\nx-foo;type=home:bar\r\nX-EMPTY:\r\nNOTE:\r\nEND:VCARD\r\n")
+	out, err := write(v, "3.0")
+	if err != nil || !strings.Contains(out, "\r\nX-FOO;TYPE=home:bar\r\n") || strings.Contains(out, "X-EMPTY") || strings.Contains(out, "NOTE") {
+		t.Fatal(err, out)
+	}
+	// N is not required in 4.0
+	if out, err := write(&VCard{FormattedName: "a"}, "4.0"); err != nil || !strings.Contains(out, "\r\nFN:a\r\n") {
+		t.Fatal(err, out)
+	}
+}
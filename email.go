@@ -0,0 +1,63 @@
+package vcard
+
+import (
+	"errors"
+	"strings"
+)
+
+// trim the address and lowercase its domain, the address before
+// normalization is kept in Original if it changed.
+// return an error if the address is not of the form local@domain
+func (email *Email) Normalize() error {
+	return email.normalize(false)
+}
+
+// same as Normalize but lowercase the whole address, most providers
+// ignoring the case of the local part
+func (email *Email) NormalizeAll() error {
+	return email.normalize(true)
+}
+
+func (email *Email) normalize(all bool) error {
+	address := strings.TrimSpace(email.Address)
+	at := strings.LastIndex(address, "@")
+	if at <= 0 || at == len(address)-1 || strings.ContainsAny(address[at+1:], " \t") {
+		return errors.New("vcard: invalid email address " + email.Address)
+	}
+	if all {
+		address = strings.ToLower(address)
+	} else {
+		address = address[:at+1] + strings.ToLower(address[at+1:])
+	}
+	if address != email.Address {
+		if email.Original == "" {
+			email.Original = email.Address
+		}
+		email.Address = address
+	}
+	return nil
+}
+
+// separators of the words of an email local part, e.g: john.doe
+var localPartSeparators = strings.NewReplacer(".", " ", "_", " ", "-", " ")
+
+// return a minimal vcard with the email address, its formatted name and
+// name are derived from the local part, e.g: "john doe" for
+// john.doe+news@example.com
+func NewFromEmail(addr string) *VCard {
+	addr = strings.TrimSpace(addr)
+	name := addr
+	if at := strings.LastIndex(addr, "@"); at > 0 {
+		name = addr[:at]
+	}
+	if plus := strings.Index(name, "+"); plus > 0 {
+		name = name[:plus]
+	}
+	name = strings.Join(strings.Fields(localPartSeparators.Replace(name)), " ")
+	if name == "" {
+		name = addr
+	}
+	vcard := &VCard{FormattedName: name, Emails: []Email{{Type: TypeSet{"INTERNET"}, Address: addr}}}
+	vcard.GivenNames, vcard.FamilyNames = ParseName(name)
+	return vcard
+}
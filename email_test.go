@@ -0,0 +1,37 @@
+package vcard
+
+import (
+	"testing"
+)
+
+func TestEmailNormalize(t *testing.T) {
+	for _, test := range []struct {
+		address, normalized, all, original string
+	}{
+		{" John.Doe@Example.COM ", "John.Doe@example.com", "john.doe@example.com", " John.Doe@Example.COM "},
+		{"john@example.com", "john@example.com", "john@example.com", ""},
+		{"a@b@Example.com", "a@b@example.com", "a@b@example.com", "a@b@Example.com"},
+	} {
+		email := Email{Address: test.address}
+		if err := email.Normalize(); err != nil || email.Address != test.normalized || email.Original != test.original {
+			t.Fatalf("%q: %+v %v", test.address, email, err)
+		}
+		email = Email{Address: test.address}
+		if err := email.NormalizeAll(); err != nil || email.Address != test.all || email.Original != test.original {
+			t.Fatalf("%q: %+v %v", test.address, email, err)
+		}
+	}
+	// the address read is kept by a second normalization
+	email := Email{Address: "John@EXAMPLE.com"}
+	email.Normalize()
+	email.NormalizeAll()
+	if email.Address != "john@example.com" || email.Original != "John@EXAMPLE.com" {
+		t.Fatalf("%+v", email)
+	}
+	for _, address := range []string{"", "john", "@example.com", "john@", "john@exa mple.com"} {
+		email := Email{Address: address}
+		if err := email.Normalize(); err == nil || email.Address != address {
+			t.Fatalf("%q: %+v", address, email)
+		}
+	}
+}
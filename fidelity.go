@@ -0,0 +1,121 @@
+package vcard
+
+import (
+	"strings"
+)
+
+// the properties whose parameters are kept by typedParams
+var typedProperties = map[string]bool{
+	"ADR": true, "TEL": true, "EMAIL": true, "URL": true, "IMPP": true, "X-JABBER": true, "X-MS-IMADDRESS": true,
+}
+
+// the parameters following the value written, or decoded when read like
+// ENCODING and CHARSET, which are not taken from the content line read by
+// restoreParams when the property is written without them
+var modeledParams = map[string]bool{
+	"ENCODING": true, "CHARSET": true, "VALUE": true, "TYPE": true, "MEDIATYPE": true, "SORT-AS": true,
+}
+
+// record the content line read in fidelity mode for restoreParams
+func (vcard *VCard) addSource(contentLine *ContentLine) {
+	name := strings.ToUpper(contentLine.Name)
+	if typedProperties[name] {
+		return
+	}
+	if vcard.sources == nil {
+		vcard.sources = make(map[string][]*ContentLine)
+	}
+	vcard.sources[name] = append(vcard.sources[name], contentLine)
+}
+
+// return the content line of a modeled property with the parameters of the
+// content line it was read from in fidelity mode, matched by occurrence,
+// e.g: the second TITLE written takes the parameters of the second TITLE
+// read. the parameters read keep their casing and order, the ones written
+// for the property taking its value, e.g: TYPE=JPEG of a PHOTO
+func (di *DirectoryInfoWriter) restoreParams(contentLine *ContentLine) *ContentLine {
+	name := strings.ToUpper(contentLine.Name)
+	if typedProperties[name] || contentLine.ParamOrder != nil {
+		return contentLine
+	}
+	if di.written == nil {
+		di.written = make(map[string]int)
+	}
+	i := di.written[name]
+	di.written[name]++
+	sources := di.sources[name]
+	if i >= len(sources) || len(sources[i].Params) == 0 {
+		return contentLine
+	}
+	source := sources[i]
+	restored := *contentLine
+	restored.Params = make(map[string]Value, len(contentLine.Params)+len(source.Params))
+	for key, values := range contentLine.Params {
+		restored.Params[key] = values
+	}
+	seen := make(map[string]bool)
+	for _, key := range paramKeys(source) {
+		// the first casing read is kept, e.g: x-a of x-a and X-A
+		if seen[strings.ToUpper(key)] {
+			continue
+		}
+		seen[strings.ToUpper(key)] = true
+		// the value written, with the casing read
+		for written, values := range restored.Params {
+			if written != key && strings.EqualFold(written, key) {
+				delete(restored.Params, written)
+				restored.Params[key] = values
+			}
+		}
+		if _, ok := restored.Params[key]; !ok {
+			if modeledParams[strings.ToUpper(key)] {
+				continue
+			}
+			restored.Params[key] = source.mergedParam(key)
+		}
+		restored.ParamOrder = append(restored.ParamOrder, key)
+	}
+	return &restored
+}
+
+// return the parameters to write for a property of the given types.
+// source is the content line the property was read from in fidelity mode,
+// its parameters are kept with their original casing and order, TYPE being
+// replaced by the current types. ENCODING, CHARSET and VALUE are not kept,
+// the values being written decoded and VALUE following the property. the
+// parameters whose names differ only by their casing, e.g: type and TYPE,
+// are merged under the first name read
+func typedParams(source *ContentLine, types TypeSet) (map[string]Value, []string) {
+	params := make(map[string]Value)
+	if source == nil {
+		params["type"] = Value(types)
+		return params, nil
+	}
+	typeKey := ""
+	canonical := make(map[string]string)
+	for _, key := range paramKeys(source) {
+		upper := strings.ToUpper(key)
+		switch upper {
+		case "TYPE":
+			if typeKey == "" {
+				typeKey = key
+			}
+		case "ENCODING", "CHARSET", "PREF", "GEO", "LABEL", "CC", "VALUE":
+			// values are written decoded, the others from the property
+		default:
+			if first, ok := canonical[upper]; ok {
+				params[first] = append(params[first], source.Params[key]...)
+			} else {
+				canonical[upper] = key
+				params[key] = append(Value(nil), source.Params[key]...)
+			}
+		}
+	}
+	if typeKey == "" {
+		typeKey = "type"
+	}
+	if len(types) > 0 {
+		params[typeKey] = Value(types)
+	}
+	return params, source.ParamOrder
+}
@@ -0,0 +1,27 @@
+package vcard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFoldLine(t *testing.T) {
+	line := "NOTE:" + strings.Repeat("é", 50)
+	for _, octets := range []int{-1, 0} {
+		if lines := FoldLine(line, octets); len(lines) != 1 || lines[0] != line {
+			t.Fatal(octets, lines)
+		}
+	}
+	lines := FoldLine(line, 75)
+	if len(lines) != 2 || strings.Join(lines, "") != line {
+		t.Fatal(lines)
+	}
+	for _, l := range lines {
+		if len(l) > 75 {
+			t.Fatal(len(l), l)
+		}
+	}
+	if lines := FoldLine("abcdef", 1); strings.Join(lines, "") != "abcdef" {
+		t.Fatal(lines)
+	}
+}
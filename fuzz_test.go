@@ -0,0 +1,98 @@
+package vcard
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// read s with the strict, lenient and Shift_JIS reader options, then use
+// the vcards read as an application would: validate, hash, convert and
+// write them in every version and profile
+func readAndWrite(s string) {
+	for mode := 0; mode < 3; mode++ {
+		di := NewDirectoryInfoReader(strings.NewReader(s))
+		switch mode {
+		case 1:
+			di.Fidelity = true
+			di.LenientLineBreaks = true
+			di.ImplicitBegin = true
+			di.SplitMultiValue = true
+			di.RepairMojibake = true
+			di.DefaultCharset = "windows-1252"
+		case 2:
+			di.SkipBinaryData = true
+			di.DefaultCharset = "Shift_JIS"
+			di.CharsetDecoder = decodeShiftJIS
+		}
+		var ab AddressBook
+		ab.ReadFrom(di)
+		for i := range ab.Contacts {
+			v := &ab.Contacts[i]
+			v.Validate()
+			v.ContentHash()
+			v.FlatMap()
+			v.AltNames()
+			v.Clone().Anonymize()
+			v.BirthdayDate()
+			v.DisplayName()
+			for _, version := range []string{"", "2.1", "3.0", "4.0"} {
+				var b bytes.Buffer
+				w := NewDirectoryInfoWriter(&b)
+				w.Version = version
+				w.PreserveFolding = true
+				w.EmptyFNStrategy = DeriveFromOrg
+				v.ConvertTo(version).WriteTo(w)
+				for _, profile := range []Profile{Generic, Apple, Google, Thunderbird, Outlook} {
+					v.WriteProfile(w, profile)
+				}
+			}
+		}
+	}
+	for octets := -1; octets <= 2; octets++ {
+		FoldLine(s, octets)
+	}
+}
+
+func FuzzRead(f *testing.F) {
+	f.Add("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:A\r\nN:A;B;;;\r\nTEL;TYPE=cell:1\r\nitem1.EMAIL:a@b\r\nitem1.X-ABLabel:x\r\nPHOTO;ENCODING=b:AAAA\r\nADR;LABEL=x:;;a;b;c;d;e\r\nEND:VCARD\r\n")
+	f.Add("BEGIN:VCARD\r\nVERSION:4.0\r\nFN;ALTID=1;LANGUAGE=en:A\r\nN;ALTID=1:A;B\r\nPHOTO:data:image/png;base64,AAAA\r\nBDAY:--0412\r\nEND:VCARD\r\n")
+	f.Add("VERSION:2.1\r\nN;ENCODING=QUOTED-PRINTABLE;CHARSET=SHIFT_JIS:=93=FA\r\nNOTE:caf\xe9\r\n")
+	f.Fuzz(func(t *testing.T, s string) {
+		readAndWrite(s)
+	})
+}
+
+// replay the inputs of testdata/fuzz, e.g: the crashers found by FuzzRead,
+// without the fuzzing engine
+func TestFuzzCorpus(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "fuzz", "FuzzRead", "*"))
+	if err != nil || len(files) == 0 {
+		t.Fatal("no corpus", err)
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if lines[0] != "go test fuzz v1" || len(lines) != 2 || !strings.HasPrefix(lines[1], "string(") || !strings.HasSuffix(lines[1], ")") {
+			t.Fatalf("%s: not a FuzzRead corpus file", file)
+		}
+		s, err := strconv.Unquote(strings.TrimSuffix(strings.TrimPrefix(lines[1], "string("), ")"))
+		if err != nil {
+			t.Fatal(file, err)
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s: panic: %v", file, r)
+				}
+			}()
+			readAndWrite(s)
+		}()
+	}
+}
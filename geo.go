@@ -0,0 +1,51 @@
+package vcard
+
+import (
+	"strconv"
+	"strings"
+)
+
+// a geographical position, e.g: from GEO:37.386013;-122.082932
+type GeoCoord struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// parse a 3.0 "lat;lon" or 4.0 "geo:lat,lon" position
+func parseGeo(texts []string) (*GeoCoord, bool) {
+	if len(texts) == 1 {
+		texts = strings.Split(texts[0], ",")
+	}
+	if len(texts) < 2 {
+		return nil, false
+	}
+	lat := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(texts[0])), "geo:")
+	// a geo URI may carry parameters, e.g: geo:37.386013,-122.082932;u=10
+	lon := strings.SplitN(strings.TrimSpace(texts[1]), ";", 2)[0]
+	latitude, err := strconv.ParseFloat(lat, 64)
+	if err != nil {
+		return nil, false
+	}
+	longitude, err := strconv.ParseFloat(lon, 64)
+	if err != nil {
+		return nil, false
+	}
+	return &GeoCoord{latitude, longitude}, true
+}
+
+// return the position as a geo: URI, e.g: geo:37.386013,-122.082932
+func (geo *GeoCoord) URI() string {
+	return "geo:" + formatCoord(geo.Latitude) + "," + formatCoord(geo.Longitude)
+}
+
+func formatCoord(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func (geo *GeoCoord) WriteTo(di *DirectoryInfoWriter) {
+	if di.version() == "4.0" {
+		di.WriteContentLine(&ContentLine{"", "GEO", nil, StructuredValue{Value{"geo:" + formatCoord(geo.Latitude), formatCoord(geo.Longitude)}}, nil, nil})
+	} else {
+		di.WriteContentLine(&ContentLine{"", "GEO", nil, StructuredValue{Value{formatCoord(geo.Latitude)}, Value{formatCoord(geo.Longitude)}}, nil, nil})
+	}
+}
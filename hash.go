@@ -0,0 +1,76 @@
+package vcard
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// return a hash of the content of the vcard for change detection, e.g: as a
+// map key. the properties are hashed as written, in any order and without
+// REV. names, parameter names and types are compared case-insensitively.
+// the values are hashed escaped, e.g: ORG:a;b and ORG:a\;b differ. the
+// properties of a group are hashed together whatever the group name, e.g:
+// a TEL with its X-ABLabel, as groups are renumbered when written
+func (vcard *VCard) ContentHash() string {
+	var lines []string
+	groups := make(map[string][]string)
+	di := &DirectoryInfoWriter{Version: vcard.Version, collect: func(contentLine *ContentLine) {
+		name := strings.ToUpper(contentLine.Name)
+		switch name {
+		case "BEGIN", "END", "REV":
+			return
+		}
+		var params []string
+		for key, values := range contentLine.Params {
+			key = strings.ToUpper(key)
+			values = copyStrings(values)
+			if key == "TYPE" {
+				for i := range values {
+					values[i] = strings.ToLower(values[i])
+				}
+				sort.Strings(values)
+			}
+			for i := range values {
+				values[i] = strconv.Quote(values[i])
+			}
+			params = append(params, key+"="+strings.Join(values, ","))
+		}
+		sort.Strings(params)
+		components := make([]string, len(contentLine.Value))
+		for i, value := range contentLine.Value {
+			escaped := make([]string, len(value))
+			for j, text := range value {
+				escaped[j] = escapeValue(text)
+			}
+			components[i] = strings.Join(escaped, ",")
+		}
+		line := name + ";" + strings.Join(params, ";") + ":" + strings.Join(components, ";")
+		if contentLine.Group != "" {
+			group := strings.ToLower(contentLine.Group)
+			groups[group] = append(groups[group], strconv.Quote(line))
+		} else {
+			lines = append(lines, line)
+		}
+	}}
+	vcard.WriteTo(di)
+	for _, group := range groups {
+		sort.Strings(group)
+		lines = append(lines, "group:"+strings.Join(group, ","))
+	}
+	sort.Strings(lines)
+	hash := sha256.New()
+	for _, line := range lines {
+		hash.Write([]byte(line))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// return true if the vcards have the same content, see ContentHash, e.g:
+// the same card exported twice with a different REV
+func (vcard *VCard) Equal(other *VCard) bool {
+	return vcard.ContentHash() == other.ContentHash()
+}
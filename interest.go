@@ -0,0 +1,51 @@
+package vcard
+
+// an RFC 6715 EXPERTISE, HOBBY or INTEREST property
+type Interest struct {
+	Value string
+	// LEVEL parameter, e.g: beginner, average or expert for EXPERTISE,
+	// high, medium or low for HOBBY and INTEREST
+	Level string
+}
+
+func readInterest(contentLine *ContentLine) Interest {
+	level, _ := contentLine.Param("LEVEL")
+	return Interest{contentLine.Value.GetText(), level.GetText()}
+}
+
+// write the interests as name properties, they are defined for 4.0 only
+func writeInterests(di *DirectoryInfoWriter, name string, interests []Interest) {
+	if di.version() != "4.0" {
+		return
+	}
+	for _, interest := range interests {
+		var params map[string]Value
+		if interest.Level != "" {
+			params = map[string]Value{"LEVEL": Value{interest.Level}}
+		}
+		di.WriteContentLine(&ContentLine{"", name, params, StructuredValue{Value{interest.Value}}, nil, nil})
+	}
+}
+
+// append the interests of src missing in dst
+func unionInterests(dst *[]Interest, src []Interest) {
+	for _, s := range src {
+		found := false
+		for _, d := range *dst {
+			if d.Value == s.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*dst = append(*dst, s)
+		}
+	}
+}
+
+func copyInterests(interests []Interest) []Interest {
+	if interests == nil {
+		return nil
+	}
+	return append([]Interest(nil), interests...)
+}
@@ -0,0 +1,23 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestInterestsVersion(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:a\r\nEXPERTISE;LEVEL=expert:go\r\nHOBBY:chess\r\nEND:VCARD\r\n")
+	var b bytes.Buffer
+	di := NewDirectoryInfoWriter(&b)
+	v.WriteTo(di)
+	if out := b.String(); !strings.Contains(out, "\r\nEXPERTISE;LEVEL=expert:go\r\n") || !strings.Contains(out, "\r\nHOBBY:chess\r\n") {
+		t.Fatal(out)
+	}
+	b.Reset()
+	di.Version = "3.0"
+	v.WriteTo(di)
+	if out := b.String(); strings.Contains(out, "EXPERTISE") || strings.Contains(out, "HOBBY") {
+		t.Fatal(out)
+	}
+}
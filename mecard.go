@@ -0,0 +1,127 @@
+package vcard
+
+import (
+	"errors"
+	"strings"
+)
+
+// escape the MECARD special characters '\\' ';' ',' ':' and '"'
+var meCardEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`)
+
+// return the vcard in the compact MECARD format used in QR codes, e.g:
+// MECARD:N:Doe,John;TEL:123;EMAIL:j@x.com;;
+// N, the preferred telephone and email, the first address and URL are kept
+func (vcard *VCard) MeCard() string {
+	var b strings.Builder
+	b.WriteString("MECARD:")
+	field := func(name, value string) {
+		if value != "" {
+			b.WriteString(name + ":" + value + ";")
+		}
+	}
+	if len(vcard.FamilyNames) > 0 || len(vcard.GivenNames) > 0 {
+		field("N", meCardEscaper.Replace(strings.Join(vcard.FamilyNames, " "))+","+
+			meCardEscaper.Replace(strings.Join(vcard.GivenNames, " ")))
+	} else {
+		field("N", meCardEscaper.Replace(vcard.FormattedName))
+	}
+	field("TEL", meCardEscaper.Replace(vcard.PrimaryPhone()))
+	field("EMAIL", meCardEscaper.Replace(vcard.PrimaryEmail()))
+	if len(vcard.Addresses) > 0 {
+		addr := vcard.Addresses[0]
+		var components []string
+		for _, c := range []string{addr.PostOfficeBox, addr.ExtendedAddress, addr.Street, addr.Locality, addr.Region, addr.PostalCode, addr.CountryName} {
+			components = append(components, meCardEscaper.Replace(c))
+		}
+		if adr := strings.Join(components, ","); strings.Trim(adr, ",") != "" {
+			field("ADR", adr)
+		}
+	}
+	field("URL", meCardEscaper.Replace(vcard.URL()))
+	b.WriteString(";")
+	return b.String()
+}
+
+// split s on the sep characters not escaped by a '\\', the parts are
+// returned still escaped
+func splitEscaped(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+		} else if s[i] == sep {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// remove the MECARD escaping '\\' characters
+func unescapeMeCard(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// parse a MECARD string, e.g: MECARD:N:Doe,John;TEL:123;EMAIL:j@x.com;;
+// N, TEL, EMAIL, ADR, URL, NOTE, NICKNAME and BDAY are read
+func ParseMeCard(s string) (*VCard, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 7 || !strings.EqualFold(s[:7], "MECARD:") {
+		return nil, errors.New("vcard: not a MECARD")
+	}
+	vcard := &VCard{Version: "3.0"}
+	for _, field := range splitEscaped(s[7:], ';') {
+		i := strings.Index(field, ":")
+		if i == -1 {
+			// the trailing ";;" ends the card with an empty field
+			continue
+		}
+		value := field[i+1:]
+		switch strings.ToUpper(field[:i]) {
+		case "N":
+			names := splitEscaped(value, ',')
+			vcard.FamilyNames = []string{unescapeMeCard(names[0])}
+			if len(names) > 1 {
+				vcard.GivenNames = []string{unescapeMeCard(names[1])}
+			}
+			vcard.FormattedName = strings.TrimSpace(strings.Join(append(copyStrings(vcard.GivenNames), vcard.FamilyNames...), " "))
+		case "TEL":
+			vcard.Telephones = append(vcard.Telephones, Telephone{Type: TypeSet{"voice"}, Number: unescapeMeCard(value)})
+		case "EMAIL":
+			vcard.Emails = append(vcard.Emails, Email{Type: TypeSet{"INTERNET"}, Address: unescapeMeCard(value)})
+		case "ADR":
+			var addr Address
+			if components := splitEscaped(value, ','); len(components) == addressSize {
+				addr.PostOfficeBox = unescapeMeCard(components[postOfficeBox])
+				addr.ExtendedAddress = unescapeMeCard(components[extendedAddress])
+				addr.Street = unescapeMeCard(components[street])
+				addr.Locality = unescapeMeCard(components[locality])
+				addr.Region = unescapeMeCard(components[region])
+				addr.PostalCode = unescapeMeCard(components[postalCode])
+				addr.CountryName = unescapeMeCard(components[countryName])
+			} else {
+				addr = ParseAddress(unescapeMeCard(value))
+			}
+			vcard.Addresses = append(vcard.Addresses, addr)
+		case "URL":
+			vcard.URLs = append(vcard.URLs, URL{Value: unescapeMeCard(value)})
+		case "NOTE":
+			vcard.Note = unescapeMeCard(value)
+		case "NICKNAME":
+			vcard.NickNames = append(vcard.NickNames, unescapeMeCard(value))
+		case "BDAY":
+			vcard.Birthday = unescapeMeCard(value)
+		default:
+			// other fields like SOUND or TEL-AV are ignored
+		}
+	}
+	return vcard, nil
+}
@@ -0,0 +1,61 @@
+package vcard
+
+import (
+	"testing"
+)
+
+func TestParseMeCard(t *testing.T) {
+	for _, test := range []struct {
+		mecard, family, given, tel, note string
+	}{
+		{"MECARD:N:Doe,John;TEL:123;EMAIL:j@x.com;;", "Doe", "John", "123", ""},
+		{`MECARD:N:Smith\;Jones,Ann;NOTE:a\:b\,c\\d;;`, "Smith;Jones", "Ann", "", `a:b,c\d`},
+		{`MECARD:N:Doe;NOTE:ends with \;;;`, "Doe", "", "", "ends with ;"},
+		{"MECARD:N:Doe;TEL:123", "Doe", "", "123", ""},
+		{"mecard:n:Doe;;", "Doe", "", "", ""},
+	} {
+		v, err := ParseMeCard(test.mecard)
+		if err != nil {
+			t.Fatal(test.mecard, err)
+		}
+		var given, tel string
+		if len(v.GivenNames) > 0 {
+			given = v.GivenNames[0]
+		}
+		if len(v.Telephones) > 0 {
+			tel = v.Telephones[0].Number
+		}
+		if len(v.FamilyNames) != 1 || v.FamilyNames[0] != test.family || given != test.given || tel != test.tel || v.Note != test.note {
+			t.Fatalf("%s: %+v", test.mecard, v)
+		}
+	}
+	if _, err := ParseMeCard("BEGIN:VCARD"); err == nil {
+		t.Fatal("no error for a vcard")
+	}
+}
+
+func TestMeCardRoundTrip(t *testing.T) {
+	v := &VCard{
+		FormattedName: "Ann Smith",
+		FamilyNames:   []string{"Smith;Jones"},
+		GivenNames:    []string{"Ann"},
+		Telephones:    []Telephone{{Number: "+1 555 1234"}},
+		Emails:        []Email{{Address: "ann@example.com"}},
+		Addresses:     []Address{{Street: "1 Main St, Apt 2", Locality: "Springfield", CountryName: "USA"}},
+		URLs:          []URL{{Value: "http://example.com/a:b"}},
+	}
+	read, err := ParseMeCard(v.MeCard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.FamilyNames[0] != "Smith;Jones" || read.GivenNames[0] != "Ann" || read.PrimaryPhone() != "+1 555 1234" ||
+		read.PrimaryEmail() != "ann@example.com" || read.URL() != "http://example.com/a:b" {
+		t.Fatalf("%s: %+v", v.MeCard(), read)
+	}
+	if addr := read.Addresses[0]; addr.Street != "1 Main St, Apt 2" || addr.Locality != "Springfield" || addr.CountryName != "USA" {
+		t.Fatalf("%s: %+v", v.MeCard(), addr)
+	}
+	if read.MeCard() != v.MeCard() {
+		t.Fatal(read.MeCard(), v.MeCard())
+	}
+}
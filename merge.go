@@ -0,0 +1,260 @@
+package vcard
+
+import (
+	"fmt"
+	"strings"
+)
+
+func mergeString(dst *string, src string) {
+	if *dst == "" {
+		*dst = src
+	}
+}
+
+func mergeStrings(dst *[]string, src []string) {
+	if len(*dst) == 0 {
+		*dst = copyStrings(src)
+	}
+}
+
+// append the strings of src missing in dst
+func unionStrings(dst *[]string, src []string) {
+	for _, s := range src {
+		found := false
+		for _, d := range *dst {
+			if d == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*dst = append(*dst, s)
+		}
+	}
+}
+
+func sameAddress(a, b *Address) bool {
+	return a.Label == b.Label && a.PostOfficeBox == b.PostOfficeBox &&
+		a.ExtendedAddress == b.ExtendedAddress && a.Street == b.Street &&
+		a.Locality == b.Locality && a.Region == b.Region &&
+		a.PostalCode == b.PostalCode && a.CountryName == b.CountryName &&
+		strings.Join(a.Extra, ";") == strings.Join(b.Extra, ";")
+}
+
+// call f with the group of each property of the vcard, extras included
+func (vcard *VCard) eachGroup(f func(group *string)) {
+	for i := range vcard.Addresses {
+		f(&vcard.Addresses[i].Group)
+	}
+	for i := range vcard.Telephones {
+		f(&vcard.Telephones[i].Group)
+	}
+	for i := range vcard.Emails {
+		f(&vcard.Emails[i].Group)
+	}
+	for i := range vcard.URLs {
+		f(&vcard.URLs[i].Group)
+	}
+	for i := range vcard.XJabbers {
+		f(&vcard.XJabbers[i].Group)
+	}
+	for i := range vcard.IMPPs {
+		f(&vcard.IMPPs[i].Group)
+	}
+	for i := range vcard.CustomDates {
+		f(&vcard.CustomDates[i].Group)
+	}
+	for _, extra := range vcard.Extras {
+		f(&extra.Group)
+	}
+}
+
+// rename the groups of other used by the vcard too, so the properties of
+// both cards sharing a group name, e.g: item1, keep their own X-ABLabel
+// and X-ABADR once merged
+func (vcard *VCard) separateGroups(other *VCard) {
+	taken := make(map[string]bool)
+	vcard.eachGroup(func(group *string) { taken[*group] = true })
+	used := make(map[string]bool)
+	for group := range taken {
+		used[group] = true
+	}
+	other.eachGroup(func(group *string) { used[*group] = true })
+	renamed := make(map[string]string)
+	item := 0
+	other.eachGroup(func(group *string) {
+		if *group == "" || !taken[*group] {
+			return
+		}
+		g, ok := renamed[*group]
+		if !ok {
+			for g == "" || used[g] {
+				item++
+				g = fmt.Sprintf("item%d", item)
+			}
+			used[g] = true
+			renamed[*group] = g
+		}
+		*group = g
+	})
+}
+
+// merge the properties of other into the vcard: single valued properties
+// are set only if empty, multi valued properties are appended without
+// duplicating identical addresses, emails, URLs and jabber IDs, or
+// telephones differing only by their formatting, see AddTelephone
+func (vcard *VCard) Merge(other *VCard) {
+	other = other.Clone()
+	vcard.separateGroups(other)
+	mergeString(&vcard.Version, other.Version)
+	hasFN, hasName := vcard.FormattedName != "", vcard.hasName()
+	mergeString(&vcard.FormattedName, other.FormattedName)
+	if !hasName {
+		vcard.FamilyNames = other.FamilyNames
+		vcard.GivenNames = other.GivenNames
+		vcard.AdditionalNames = other.AdditionalNames
+		vcard.HonorificNames = other.HonorificNames
+		vcard.HonorificSuffixes = other.HonorificSuffixes
+		vcard.NameExtra = other.NameExtra
+	}
+	if len(vcard.SortAs) == 0 {
+		vcard.SortAs = other.SortAs
+	}
+	if len(vcard.Alternates) == 0 {
+		// the FN and N kept don't come from the alternates of other
+		vcard.Alternates = other.Alternates
+		for i := range vcard.Alternates {
+			vcard.Alternates[i].primaryFN = vcard.Alternates[i].primaryFN && !hasFN
+			vcard.Alternates[i].primaryN = vcard.Alternates[i].primaryN && !hasName
+		}
+	}
+	mergeString(&vcard.PhoneticGiven, other.PhoneticGiven)
+	mergeString(&vcard.PhoneticFamily, other.PhoneticFamily)
+	unionStrings(&vcard.NickNames, other.NickNames)
+	if vcard.Photo.Data == "" {
+		vcard.Photo = other.Photo
+	}
+	if vcard.Birthday == "" {
+		vcard.Birthday, vcard.BirthdayScale = other.Birthday, other.BirthdayScale
+	}
+	if vcard.Anniversary == "" {
+		vcard.Anniversary, vcard.AnniversaryScale = other.Anniversary, other.AnniversaryScale
+	}
+	for _, addr := range other.Addresses {
+		found := false
+		for i := range vcard.Addresses {
+			if sameAddress(&vcard.Addresses[i], &addr) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vcard.Addresses = append(vcard.Addresses, addr)
+		}
+	}
+	for _, tel := range other.Telephones {
+		vcard.AddTelephone(tel)
+	}
+	for _, email := range other.Emails {
+		found := false
+		for _, e := range vcard.Emails {
+			if strings.EqualFold(e.Address, email.Address) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vcard.Emails = append(vcard.Emails, email)
+		}
+	}
+	unionStrings(&vcard.Titles, other.Titles)
+	unionStrings(&vcard.Roles, other.Roles)
+	mergeStrings(&vcard.Org, other.Org)
+	unionStrings(&vcard.Categories, other.Categories)
+	mergeString(&vcard.Note, other.Note)
+	for _, url := range other.URLs {
+		found := false
+		for _, u := range vcard.URLs {
+			if u.Value == url.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vcard.URLs = append(vcard.URLs, url)
+		}
+	}
+	mergeString(&vcard.TimeZone, other.TimeZone)
+	mergeString(&vcard.UID, other.UID)
+	if other.Revision.After(vcard.Revision) {
+		vcard.Revision = other.Revision
+	}
+	mergeString(&vcard.Kind, other.Kind)
+	unionStrings(&vcard.Members, other.Members)
+	vcard.XAddressBookServer = vcard.XAddressBookServer || other.XAddressBookServer
+	if vcard.Geo == nil {
+		vcard.Geo = other.Geo
+	}
+	for _, jab := range other.XJabbers {
+		found := false
+		for _, j := range vcard.XJabbers {
+			if strings.EqualFold(j.Address, jab.Address) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vcard.XJabbers = append(vcard.XJabbers, jab)
+		}
+	}
+	for _, date := range other.CustomDates {
+		found := false
+		for _, d := range vcard.CustomDates {
+			if d.Date == date.Date && strings.EqualFold(d.Label, date.Label) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vcard.CustomDates = append(vcard.CustomDates, date)
+		}
+	}
+	for _, impp := range other.IMPPs {
+		found := false
+		for _, i := range vcard.IMPPs {
+			if strings.EqualFold(i.URI, impp.URI) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			vcard.IMPPs = append(vcard.IMPPs, impp)
+		}
+	}
+	unionInterests(&vcard.Expertises, other.Expertises)
+	unionInterests(&vcard.Hobbies, other.Hobbies)
+	unionInterests(&vcard.Interests, other.Interests)
+	unionStrings(&vcard.OrgDirectories, other.OrgDirectories)
+	if vcard.Birthplace == "" {
+		vcard.BirthplaceValue = other.BirthplaceValue
+	}
+	mergeString(&vcard.Birthplace, other.Birthplace)
+	mergeString(&vcard.Deathplace, other.Deathplace)
+	if vcard.Deathdate == "" {
+		vcard.Deathdate, vcard.DeathdateScale = other.Deathdate, other.DeathdateScale
+	}
+	unionStrings(&vcard.ContactURIs, other.ContactURIs)
+	mergeString(&vcard.AgentURI, other.AgentURI)
+	mergeString(&vcard.XABuid, other.XABuid)
+	mergeString(&vcard.XABShowAs, other.XABShowAs)
+	vcard.Extras = append(vcard.Extras, other.Extras...)
+	unionStrings(&vcard.SkippedBinary, other.SkippedBinary)
+}
+
+// read the next card of di, as ReadFrom does, and merge its properties
+// into the vcard, see Merge
+func (vcard *VCard) AppendFrom(di *DirectoryInfoReader) {
+	var other VCard
+	other.ReadFrom(di)
+	vcard.Merge(&other)
+}
@@ -0,0 +1,28 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMergeGroups(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nN:a;;;;\r\nitem1.ADR:;;1 Main St;Springfield;;;\r\nitem1.X-ABADR:us\r\nEND:VCARD\r\n")
+	other := readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nN:a;;;;\r\nitem1.ADR:;;2 rue Haute;Paris;;;\r\nitem1.X-ABLabel:Holiday\r\nEND:VCARD\r\n")
+	v.Merge(other)
+	var b bytes.Buffer
+	if err := v.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, line := range []string{
+		"\r\nitem1.ADR;type=Intl,Postal,Parcel,Work:;;1 Main St;Springfield;;;\r\n",
+		"\r\nitem1.X-ABADR:us\r\n",
+		"\r\nitem2.ADR;type=Intl,Postal,Parcel,Work:;;2 rue Haute;Paris;;;\r\n",
+		"\r\nitem2.X-ABLabel:Holiday\r\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Fatal(line, out)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package vcard
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"net/textproto"
+	"strings"
+)
+
+// parse the vcards of a MIME part, e.g: an email attachment. the part
+// headers are read, the content type must be text/vcard or text/x-vcard,
+// and the body is decoded according to its Content-Transfer-Encoding
+func UnmarshalMIME(r io.Reader) ([]*VCard, error) {
+	br := bufio.NewReader(r)
+	header, err := textproto.NewReader(br).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		return nil, errors.New("vcard: missing Content-Type")
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if mediaType != "text/vcard" && mediaType != "text/x-vcard" {
+		return nil, errors.New("vcard: unexpected Content-Type " + mediaType)
+	}
+	var body io.Reader = br
+	switch encoding := strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))); encoding {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, spaceSkipper{br})
+	case "quoted-printable":
+		body = newQuotedPrintableReader(br)
+	case "", "7bit", "8bit", "binary":
+		// not encoded
+	default:
+		return nil, errors.New("vcard: unknown Content-Transfer-Encoding " + encoding)
+	}
+	return UnmarshalAll(body)
+}
@@ -0,0 +1,32 @@
+package vcard
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalMIME(t *testing.T) {
+	card := "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:José\r\nN:;José;;;\r\nEND:VCARD\r\n"
+	encoded := base64.StdEncoding.EncodeToString([]byte(card))
+	for _, part := range []string{
+		"Content-Type: text/vcard; charset=utf-8\r\n\r\n" + card,
+		"Content-Type: text/x-vcard\r\nContent-Transfer-Encoding: 8bit\r\n\r\n" + card,
+		"Content-Type: text/vcard\r\nContent-Transfer-Encoding: base64\r\n\r\n" + encoded[:40] + "\r\n" + encoded[40:] + "\r\n",
+		"Content-Type: text/vcard\r\nContent-Transfer-Encoding: Quoted-Printable\r\n\r\nBEGIN:VCARD\r\nVERSION:3.0\r\nFN:Jos=C3=A9\r\nN:;Jo=\r\ns=C3=A9;;;\r\nEND:VCARD\r\n",
+	} {
+		cards, err := UnmarshalMIME(strings.NewReader(part))
+		if err != nil || len(cards) != 1 || cards[0].FormattedName != "José" || cards[0].GivenNames[0] != "José" {
+			t.Fatalf("%q: %+v %v", part, cards, err)
+		}
+	}
+	for _, part := range []string{
+		"\r\n" + card,
+		"Content-Type: text/plain\r\n\r\n" + card,
+		"Content-Type: text/vcard\r\nContent-Transfer-Encoding: uuencode\r\n\r\n" + card,
+	} {
+		if cards, err := UnmarshalMIME(strings.NewReader(part)); err == nil {
+			t.Fatalf("%q: %+v", part, cards)
+		}
+	}
+}
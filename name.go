@@ -0,0 +1,132 @@
+package vcard
+
+import (
+	"strings"
+)
+
+// lowercase particles which belong to the family name when preceding it,
+// e.g: "Ludwig van Beethoven" or "Charles de Gaulle"
+var familyNameParticles = map[string]bool{
+	"van": true, "von": true, "der": true, "den": true, "de": true,
+	"del": true, "della": true, "di": true, "da": true, "du": true,
+	"des": true, "la": true, "le": true, "ter": true, "ten": true,
+	"bin": true, "ibn": true, "al": true, "dos": true, "das": true,
+}
+
+// best effort split of a formatted name into given and family names.
+// "Last, First" and "First Last" forms are handled, lowercase particles
+// before the last word are kept with the family name.
+func ParseName(fn string) (given, family []string) {
+	fn = strings.TrimSpace(fn)
+	if fn == "" {
+		return nil, nil
+	}
+	if i := strings.Index(fn, ","); i != -1 {
+		last := strings.TrimSpace(fn[:i])
+		first := strings.Join(strings.Fields(fn[i+1:]), " ")
+		if last != "" {
+			family = []string{last}
+		}
+		if first != "" {
+			given = []string{first}
+		}
+		return given, family
+	}
+	words := strings.Fields(fn)
+	if len(words) == 1 {
+		return []string{words[0]}, nil
+	}
+	start := len(words) - 1
+	for start > 1 && familyNameParticles[words[start-1]] {
+		start--
+	}
+	return []string{strings.Join(words[:start], " ")}, []string{strings.Join(words[start:], " ")}
+}
+
+// common Chinese, Japanese and Korean surnames, used by SplitCJKName.
+// entries may be added, e.g: CJKSurnames["東海林"] = true
+var CJKSurnames = map[string]bool{}
+
+func init() {
+	for _, surnames := range []string{
+		// Chinese, simplified and traditional
+		"王 李 张 張 刘 劉 陈 陳 杨 楊 黄 黃 赵 趙 吴 吳 周 徐 孙 孫 马 馬 朱 胡 郭 何 林 高 罗 羅 " +
+			"郑 鄭 梁 谢 謝 宋 唐 许 許 韩 韓 冯 馮 邓 鄧 曹 彭 曾 萧 蕭 田 董 潘 袁 蔡 蒋 蔣 余 于 " +
+			"杜 叶 葉 程 魏 苏 蘇 吕 呂 丁 任 卢 盧 姚 沈 钟 鍾 姜 崔 谭 譚 陆 陸 范 汪 廖 石 金 贾 賈 " +
+			"夏 方 邹 鄒 熊 白 孟 秦 邱 侯 江 尹 薛 段 雷 龙 龍 黎 史 陶 贺 賀 毛 郝 顾 顧 龚 龔 邵 " +
+			"万 萬 武 钱 錢 戴 严 嚴 莫 孔 向 常 汤 湯 " +
+			"欧阳 歐陽 司马 司馬 诸葛 諸葛 上官 东方 東方 皇甫 公孙 公孫 令狐 慕容 夏侯 西门 西門",
+		// Japanese
+		"佐藤 鈴木 高橋 田中 伊藤 渡辺 渡邊 山本 中村 小林 加藤 吉田 山田 佐々木 山口 松本 井上 " +
+			"木村 斎藤 斉藤 清水 山崎 森 池田 橋本 阿部 石川 山下 中島 石井 小川 前田 岡田 長谷川 " +
+			"藤田 後藤 近藤 村上 遠藤 青木 坂本 福田 太田 西村 藤井 金子 岡本 藤原 中野 三浦 原田 " +
+			"中川 松田 竹内 小野 田村 中山 和田 石田 森田 上田 原 内田 柴田 酒井 宮崎 横山 高木 " +
+			"安藤 宮本 大野 小島 谷口 今井 工藤 高田 増田 丸山 杉山 村田 大塚 新井 小山 平野 藤本 " +
+			"河野 上野 野口 武田 松井 千葉 岩崎 菅原 木下 久保 佐野 野村 松尾 市川 菊地 杉本 古川 " +
+			"大西 島田 水野 桜井 高野 渡部 吉川 山内 西田 飯田 菅野 北村 中田 佐久間",
+		// Korean
+		"김 이 박 최 정 강 조 윤 장 임 한 오 서 신 권 황 안 송 류 유 전 홍 고 문 양 손 배 백 허 " +
+			"남 심 노 하 곽 성 차 주 우 구 민 나 진 지 엄 채 원 천 방 공 현 함 변 염 여 추 도 소 " +
+			"석 선 설 마 길 연 위 표 명 기 반 왕 금 옥 육 인 맹 제 모 탁 국 어 은 편 용 " +
+			"남궁 황보 제갈 선우 독고 사공 서문",
+	} {
+		for _, surname := range strings.Fields(surnames) {
+			CJKSurnames[surname] = true
+		}
+	}
+}
+
+// split a Chinese, Japanese or Korean formatted name written family name
+// first, e.g: "山田太郎" in "山田" and "太郎". without space between the
+// names, the longest surname of CJKSurnames prefixing fn is the family
+// name. an unknown surname is taken to be the first character of names of
+// two or three characters, usual in Chinese and Korean, longer names are
+// returned as given name only
+func SplitCJKName(fn string) (family, given string) {
+	fn = strings.TrimSpace(fn)
+	if words := strings.Fields(fn); len(words) > 1 {
+		return words[0], strings.Join(words[1:], " ")
+	}
+	runes := []rune(fn)
+	for n := 3; n > 0; n-- {
+		if n < len(runes) && CJKSurnames[string(runes[:n])] {
+			return string(runes[:n]), string(runes[n:])
+		}
+	}
+	if len(runes) == 2 || len(runes) == 3 {
+		return string(runes[:1]), string(runes[1:])
+	}
+	return "", fn
+}
+
+// return the name to display for the vcard: FN, else the name built from
+// N, else the organization, else the local part of the first email,
+// else "Unknown"
+func (vcard *VCard) DisplayName() string {
+	if name := strings.TrimSpace(vcard.FormattedName); name != "" {
+		return name
+	}
+	var parts []string
+	for _, names := range [][]string{vcard.HonorificNames, vcard.GivenNames, vcard.AdditionalNames, vcard.FamilyNames, vcard.HonorificSuffixes} {
+		for _, name := range names {
+			if name = strings.TrimSpace(name); name != "" {
+				parts = append(parts, name)
+			}
+		}
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, " ")
+	}
+	if len(vcard.Org) > 0 && strings.TrimSpace(vcard.Org[0]) != "" {
+		return strings.TrimSpace(vcard.Org[0])
+	}
+	if len(vcard.Emails) > 0 {
+		address := strings.TrimSpace(vcard.Emails[0].Address)
+		if at := strings.LastIndex(address, "@"); at > 0 {
+			return address[:at]
+		} else if address != "" {
+			return address
+		}
+	}
+	return "Unknown"
+}
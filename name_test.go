@@ -0,0 +1,54 @@
+package vcard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseName(t *testing.T) {
+	for _, test := range []struct {
+		fn, given, family string
+	}{
+		{"John Smith", "John", "Smith"},
+		{"  John   Ronald Smith ", "John Ronald", "Smith"},
+		{"Smith, John", "John", "Smith"},
+		{"Smith,  John   Ronald", "John Ronald", "Smith"},
+		{"Ludwig van Beethoven", "Ludwig", "van Beethoven"},
+		{"Vincent van der Berg", "Vincent", "van der Berg"},
+		{"Charles de Gaulle", "Charles", "de Gaulle"},
+		{"Madonna", "Madonna", ""},
+		{"Smith,", "", "Smith"},
+		{"", "", ""},
+	} {
+		given, family := ParseName(test.fn)
+		if strings.Join(given, "|") != test.given || strings.Join(family, "|") != test.family {
+			t.Fatalf("%q: %q %q", test.fn, given, family)
+		}
+	}
+}
+
+func TestSplitCJKName(t *testing.T) {
+	for _, test := range []struct {
+		fn, family, given string
+	}{
+		{"山田太郎", "山田", "太郎"},
+		{"佐々木健", "佐々木", "健"},
+		{"欧阳修", "欧阳", "修"},
+		{"王小明", "王", "小明"},
+		{"김민준", "김", "민준"},
+		{"남궁민", "남궁", "민"},
+		{"山田 太郎", "山田", "太郎"},
+		{"甲乙", "甲", "乙"},
+		{"甲乙丙丁", "", "甲乙丙丁"},
+		{"森", "", "森"},
+	} {
+		if family, given := SplitCJKName(test.fn); family != test.family || given != test.given {
+			t.Fatalf("%q: %q %q", test.fn, family, given)
+		}
+	}
+	CJKSurnames["甲乙"] = true
+	defer delete(CJKSurnames, "甲乙")
+	if family, given := SplitCJKName("甲乙丙丁"); family != "甲乙" || given != "丙丁" {
+		t.Fatal(family, given)
+	}
+}
@@ -0,0 +1,177 @@
+package vcard
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// a telephone number decomposed in its components, e.g: +1 217 555 0100 ext. 12
+type PhoneNumber struct {
+	CountryCode    string // e.g: 1
+	NationalNumber string // e.g: 2175550100
+	Extension      string // e.g: 12
+}
+
+// country calling codes of some regions, identified by ISO 3166 code
+var regionCallingCodes = map[string]string{
+	"US": "1", "CA": "1", "RU": "7", "ZA": "27", "NL": "31", "BE": "32",
+	"FR": "33", "ES": "34", "IT": "39", "CH": "41", "AT": "43", "GB": "44",
+	"DK": "45", "SE": "46", "NO": "47", "PL": "48", "DE": "49", "MX": "52",
+	"BR": "55", "AU": "61", "NZ": "64", "JP": "81", "KR": "82", "CN": "86",
+	"IN": "91", "PT": "351", "IE": "353", "FI": "358",
+}
+
+// the two digit country calling codes, the others have one or three digits
+var twoDigitCallingCodes = map[string]bool{
+	"20": true, "27": true, "30": true, "31": true, "32": true, "33": true,
+	"34": true, "36": true, "39": true, "40": true, "41": true, "43": true,
+	"44": true, "45": true, "46": true, "47": true, "48": true, "49": true,
+	"51": true, "52": true, "53": true, "54": true, "55": true, "56": true,
+	"57": true, "58": true, "60": true, "61": true, "62": true, "63": true,
+	"64": true, "65": true, "66": true, "81": true, "82": true, "84": true,
+	"86": true, "90": true, "91": true, "92": true, "93": true, "94": true,
+	"95": true, "98": true,
+}
+
+// an extension, e.g: ;ext=12, x12 or ext. 12
+var phoneExtension = regexp.MustCompile(`(?i)(?:;ext=|\s*(?:ext\.?|x)\s*)(\d+)$`)
+
+// separators allowed in a telephone number
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "", "/", "", "\u00a0", "")
+
+// decompose the telephone number, a national number being in defaultRegion,
+// an ISO 3166 code like "US". the raw Number is left unchanged
+func (tel Telephone) Parse(defaultRegion string) (PhoneNumber, error) {
+	var number PhoneNumber
+	text := strings.TrimSpace(tel.Number)
+	if len(text) >= 4 && strings.EqualFold(text[:4], "tel:") {
+		text = text[4:]
+	}
+	if m := phoneExtension.FindStringSubmatchIndex(text); m != nil {
+		number.Extension = text[m[2]:m[3]]
+		text = text[:m[0]]
+	}
+	digits := phoneSeparators.Replace(text)
+	international := strings.HasPrefix(digits, "+")
+	if international {
+		digits = digits[1:]
+	}
+	if digits == "" {
+		return number, errors.New("vcard: empty telephone number")
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return number, errors.New("vcard: invalid telephone number " + tel.Number)
+		}
+	}
+	region := strings.ToUpper(defaultRegion)
+	code, known := regionCallingCodes[region]
+	if !international {
+		switch {
+		case strings.HasPrefix(digits, "00"):
+			digits, international = digits[2:], true
+		case code == "1" && strings.HasPrefix(digits, "011"):
+			digits, international = digits[3:], true
+		}
+	}
+	if international {
+		n := 3
+		if digits[0] == '1' || digits[0] == '7' {
+			n = 1
+		} else if len(digits) >= 2 && twoDigitCallingCodes[digits[:2]] {
+			n = 2
+		}
+		if len(digits) <= n {
+			return number, errors.New("vcard: invalid telephone number " + tel.Number)
+		}
+		number.CountryCode, number.NationalNumber = digits[:n], digits[n:]
+		return number, nil
+	}
+	if !known {
+		return number, errors.New("vcard: unknown region " + defaultRegion)
+	}
+	if code == "1" {
+		// North American numbers may be dialed with the leading 1
+		if len(digits) == 11 && digits[0] == '1' {
+			digits = digits[1:]
+		}
+	} else if region != "IT" {
+		// drop the trunk prefix, kept in Italian numbers
+		digits = strings.TrimPrefix(digits, "0")
+	}
+	number.CountryCode, number.NationalNumber = code, digits
+	return number, nil
+}
+
+// return a minimal vcard with the telephone number, also used as its
+// formatted name. N is left empty, the number is not a name: it is written
+// without components before 4.0, which a Strict writer rejects
+func NewFromPhone(number string) *VCard {
+	number = strings.TrimSpace(number)
+	return &VCard{FormattedName: number, Telephones: []Telephone{{Type: TypeSet{"voice"}, Number: number}}}
+}
+
+// return the digits of a telephone number and of its extension, e.g:
+// "15551234" and "12" for +1 555 1234 ext. 12
+func phoneDigits(number string) (digits, extension string) {
+	if m := phoneExtension.FindStringSubmatchIndex(number); m != nil {
+		extension = number[m[2]:m[3]]
+		number = number[:m[0]]
+	}
+	for _, c := range number {
+		if c >= '0' && c <= '9' {
+			digits += string(c)
+		}
+	}
+	return digits, extension
+}
+
+// the minimum digits of a number matched as the suffix of another, shorter
+// numbers must be equal
+const minPhoneSuffix = 7
+
+// return true if the telephone numbers differ only by their formatting:
+// their extensions are equal and the digits of one end the digits of the
+// other, e.g: +1 555 1234 and (555) 1234
+func SamePhoneNumber(a, b string) bool {
+	digitsA, extA := phoneDigits(a)
+	digitsB, extB := phoneDigits(b)
+	if extA != extB || digitsA == "" || digitsB == "" {
+		return a == b
+	}
+	if len(digitsA) < len(digitsB) {
+		digitsA, digitsB = digitsB, digitsA
+	}
+	if len(digitsB) < minPhoneSuffix {
+		return digitsA == digitsB
+	}
+	return strings.HasSuffix(digitsA, digitsB)
+}
+
+// return true if number is formatted better than other: it has more
+// digits, e.g: a country code, or is international
+func betterPhoneNumber(number, other string) bool {
+	digits, _ := phoneDigits(number)
+	otherDigits, _ := phoneDigits(other)
+	if len(digits) != len(otherDigits) {
+		return len(digits) > len(otherDigits)
+	}
+	return strings.HasPrefix(strings.TrimSpace(number), "+") && !strings.HasPrefix(strings.TrimSpace(other), "+")
+}
+
+// add the telephone unless the vcard has the same number, see
+// SamePhoneNumber, whose number is then replaced by the better formatted
+// of the two
+func (vcard *VCard) AddTelephone(tel Telephone) {
+	for i := range vcard.Telephones {
+		t := &vcard.Telephones[i]
+		if SamePhoneNumber(t.Number, tel.Number) {
+			if betterPhoneNumber(tel.Number, t.Number) {
+				t.Number = tel.Number
+			}
+			return
+		}
+	}
+	vcard.Telephones = append(vcard.Telephones, tel)
+}
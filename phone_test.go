@@ -0,0 +1,82 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNewFromPhone(t *testing.T) {
+	v := NewFromPhone(" +1 217 555 0100 ")
+	if v.FormattedName != "+1 217 555 0100" || v.hasName() || v.Telephones[0].Number != "+1 217 555 0100" {
+		t.Fatalf("%+v", v)
+	}
+	var b bytes.Buffer
+	di := NewDirectoryInfoWriter(&b)
+	v.WriteTo(di)
+	if di.Err() != nil || !strings.Contains(b.String(), "\r\nN:;;;;\r\nTEL;type=voice:+1 217 555 0100\r\n") {
+		t.Fatal(di.Err(), b.String())
+	}
+	b.Reset()
+	di = NewDirectoryInfoWriter(&b)
+	di.Strict = true
+	di.Version = "4.0"
+	v.WriteTo(di)
+	if di.Err() != nil || !strings.Contains(b.String(), "\r\nFN:+1 217 555 0100\r\n") {
+		t.Fatal(di.Err(), b.String())
+	}
+}
+
+func TestTelephoneParse(t *testing.T) {
+	for _, test := range []struct {
+		number, region string
+		want           PhoneNumber
+	}{
+		{"+1 (217) 555-0100", "", PhoneNumber{"1", "2175550100", ""}},
+		{"tel:+33 1 23 45 67 89", "US", PhoneNumber{"33", "123456789", ""}},
+		{"+353 1 234 5678", "", PhoneNumber{"353", "12345678", ""}},
+		{"0044 20 7946 0958", "FR", PhoneNumber{"44", "2079460958", ""}},
+		{"011 49 30 1234567", "US", PhoneNumber{"49", "301234567", ""}},
+		{"(217) 555-0100 ext. 12", "us", PhoneNumber{"1", "2175550100", "12"}},
+		{"1-217-555-0100 x7", "CA", PhoneNumber{"1", "2175550100", "7"}},
+		{"+1 217 555 0100;ext=3", "", PhoneNumber{"1", "2175550100", "3"}},
+		{"01 23 45 67 89", "FR", PhoneNumber{"33", "123456789", ""}},
+		{"06 1234 5678", "IT", PhoneNumber{"39", "0612345678", ""}},
+	} {
+		number, err := Telephone{Number: test.number}.Parse(test.region)
+		if err != nil || number != test.want {
+			t.Fatalf("%q: %+v %v", test.number, number, err)
+		}
+	}
+	for _, test := range []struct{ number, region string }{
+		{"", "US"},
+		{"call me", "US"},
+		{"555 0100", "XX"},
+		{"+1", ""},
+	} {
+		if number, err := (Telephone{Number: test.number}).Parse(test.region); err == nil {
+			t.Fatalf("%q: %+v", test.number, number)
+		}
+	}
+}
+
+func TestSamePhoneNumber(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		same bool
+	}{
+		{"+1 555 123 4567", "(555) 123-4567", true},
+		{"555.123.4567", "5551234567", true},
+		{"+1 555 123 4567 ext. 12", "555 123 4567 x12", true},
+		{"+1 555 123 4567 ext. 12", "555 123 4567", false},
+		{"+1 555 123 4567", "+1 555 123 4568", false},
+		{"12 34", "1234", true},
+		{"1234", "01234", false},
+		{"call me", "call me", true},
+		{"call me", "call you", false},
+	} {
+		if SamePhoneNumber(test.a, test.b) != test.same || SamePhoneNumber(test.b, test.a) != test.same {
+			t.Fatalf("%q %q", test.a, test.b)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package vcard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// return true if the photo data is a URI rather than inline data
+func (photo *Photo) isURI() bool {
+	return strings.EqualFold(photo.Value, "uri") || strings.EqualFold(photo.Value, "url")
+}
+
+// return true if the photo data is inline base64 data, e.g: ENCODING=b
+func (photo *Photo) isBase64() bool {
+	return strings.EqualFold(photo.Encoding, "b") || strings.EqualFold(photo.Encoding, "base64")
+}
+
+// decode the inline base64 photo data
+func (photo *Photo) decode() ([]byte, error) {
+	return decodeBase64(photo.Data)
+}
+
+// remove the whitespace of base64 data, e.g: spaces or tabs left inside
+// the data by an improper folding
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// decode base64 data, tolerating whitespace anywhere and a missing padding
+func decodeBase64(s string) ([]byte, error) {
+	s = stripSpace(s)
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		if raw, rawErr := base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "=")); rawErr == nil {
+			return raw, nil
+		}
+	}
+	return data, err
+}
+
+// drop the whitespace read from reader, the base64 decoder of the standard
+// library only ignoring line breaks
+type spaceSkipper struct {
+	reader io.Reader
+}
+
+func (ss spaceSkipper) Read(p []byte) (int, error) {
+	n, err := ss.reader.Read(p)
+	kept := 0
+	for _, b := range p[:n] {
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
+
+// return the photo to write: an inline photo larger than PhotoThreshold
+// bytes is replaced by a URI to the data stored by ExternalizePhoto
+func (di *DirectoryInfoWriter) externalize(photo *Photo) *Photo {
+	if di.ExternalizePhoto == nil || photo.isURI() || len(photo.Data) == 0 {
+		return photo
+	}
+	// base64 encodes 3 bytes in 4 characters, don't decode small photos
+	if len(photo.Data)*3/4 <= di.PhotoThreshold {
+		return photo
+	}
+	data, err := photo.decode()
+	if err != nil {
+		// written inline as read, the other photos and cards being written
+		di.logf("Photo not externalized: %s\n", err)
+		return photo
+	}
+	if len(data) <= di.PhotoThreshold {
+		return photo
+	}
+	uri, err := di.ExternalizePhoto(data, mediaTypeFromToken(photo.Type))
+	if err != nil {
+		if di.err == nil {
+			di.err = err
+		}
+		return photo
+	}
+	return &Photo{Type: photo.Type, Value: "uri", Data: uri}
+}
+
+// return the photo to write with the inline photo encoding of the writer
+// profile, if any
+func (di *DirectoryInfoWriter) encodePhoto(photo *Photo) *Photo {
+	if di.photoEncoding == "" || photo.isURI() || len(photo.Data) == 0 {
+		return photo
+	}
+	encoded := *photo
+	encoded.Encoding = di.photoEncoding
+	return &encoded
+}
+
+// return the photo of a 4.0 base64 data URI, e.g:
+// PHOTO:data:image/jpeg;base64,/9j/4AAQ... false if the value is not one.
+// the ';' and ',' of the URI split the value in components and values
+func dataURIPhoto(value StructuredValue) (Photo, bool) {
+	if len(value) != 2 || len(value[0]) != 1 || len(value[1]) != 2 {
+		return Photo{}, false
+	}
+	mediaType := value[0][0]
+	if len(mediaType) < 5 || !strings.EqualFold(mediaType[:5], "data:") || !strings.EqualFold(value[1][0], "base64") {
+		return Photo{}, false
+	}
+	return Photo{Encoding: "b", Type: mediaType[5:], Data: value[1][1]}, true
+}
+
+// return the inline photo as a data URI, written as the 4.0 PHOTO value,
+// the whitespace of the base64 data is removed. the line is folded like any
+// other and the reader unfolds it
+func (photo *Photo) dataURI() StructuredValue {
+	data := stripSpace(photo.Data)
+	return StructuredValue{Value{"data:" + mediaTypeFromToken(photo.Type)}, Value{"base64", data}}
+}
+
+// return the media type of the inline photo detected from its data,
+// e.g: image/png
+func (photo *Photo) DetectType() (string, error) {
+	// base64 has no ':', unlike the 4.0 photo URIs without VALUE parameter
+	if photo.isURI() || strings.Contains(photo.Data, ":") {
+		return "", errors.New("vcard: photo data is a URI")
+	}
+	data, err := photo.decode()
+	if err != nil {
+		return "", err
+	}
+	if len(data) == 0 {
+		return "", errors.New("vcard: photo has no data")
+	}
+	mediaType := http.DetectContentType(data)
+	if i := strings.Index(mediaType, ";"); i != -1 {
+		mediaType = mediaType[:i]
+	}
+	return mediaType, nil
+}
+
+// return an error if the declared type of the inline photo doesn't match
+// its data, e.g: TYPE=JPEG for PNG data. a photo without declared type,
+// given by URI or whose data isn't a known image format is not checked
+func (photo *Photo) Verify() error {
+	if photo.Type == "" || photo.isURI() || photo.Data == "" || strings.Contains(photo.Data, ":") {
+		return nil
+	}
+	detected, err := photo.DetectType()
+	if err != nil {
+		return err
+	}
+	declared := mediaTypeFromToken(photo.Type)
+	if strings.HasPrefix(detected, "image/") && detected != declared {
+		return fmt.Errorf("vcard: photo declared as %s is %s", declared, detected)
+	}
+	return nil
+}
+
+// inline the photo file referenced by a relative path or a file: URI, e.g:
+// PHOTO;VALUE=uri:photo1.jpg exported beside the vcf file in baseDir.
+// paths leading outside baseDir are rejected, symbolic links included,
+// other URIs are left unchanged
+func (photo *Photo) Resolve(baseDir string) error {
+	if !photo.isURI() || photo.Data == "" {
+		return nil
+	}
+	ref, err := url.Parse(photo.Data)
+	if err != nil {
+		return err
+	}
+	if ref.Scheme != "" && !strings.EqualFold(ref.Scheme, "file") {
+		return nil
+	}
+	base, err := filepath.Abs(baseDir)
+	if err == nil {
+		base, err = filepath.EvalSymlinks(base)
+	}
+	if err != nil {
+		return err
+	}
+	path := filepath.FromSlash(ref.Path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	// a symbolic link is checked by its target
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errors.New("vcard: photo " + photo.Data + " is outside " + baseDir)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	photo.Value = ""
+	photo.Encoding = "b"
+	photo.Data = base64.StdEncoding.EncodeToString(data)
+	if photo.Type == "" {
+		if mediaType, err := photo.DetectType(); err == nil && strings.HasPrefix(mediaType, "image/") {
+			photo.Type = mediaType
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package vcard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// a Logger keeping the messages
+type captureLogger struct {
+	messages []string
+}
+
+func (l *captureLogger) Printf(format string, v ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func TestLargePhotoRoundTrip(t *testing.T) {
+	data := make([]byte, 400*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+	v := &VCard{FormattedName: "a", Photo: Photo{Encoding: "b", Type: "JPEG", Data: base64.StdEncoding.EncodeToString(data)}}
+	var b bytes.Buffer
+	di := NewDirectoryInfoWriter(&b)
+	di.Version = "4.0"
+	v.WriteTo(di)
+	if di.Err() != nil {
+		t.Fatal(di.Err())
+	}
+	for _, line := range strings.Split(b.String(), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Fatalf("%d octets: %q", len(line), line)
+		}
+	}
+	read := readCard(t, b.String())
+	if read.Photo.Type != "image/jpeg" {
+		t.Fatalf("%+v", read.Photo.Type)
+	}
+	decoded, err := read.Photo.decode()
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Fatal("photo data differs", err)
+	}
+}
+
+func TestPhotoURIRoundTrip40(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:a\r\nPHOTO:http://example.com/a.jpg\r\nEND:VCARD\r\n")
+	if !v.Photo.isURI() || v.Photo.Data != "http://example.com/a.jpg" {
+		t.Fatalf("%+v", v.Photo)
+	}
+	var b bytes.Buffer
+	v.WriteTo(NewDirectoryInfoWriter(&b))
+	if !strings.Contains(b.String(), "\r\nPHOTO;VALUE=uri:http://example.com/a.jpg\r\n") {
+		t.Fatalf("%q", b.String())
+	}
+	v.Photo.Value = ""
+	b.Reset()
+	v.WriteTo(NewDirectoryInfoWriter(&b))
+	if strings.Contains(b.String(), "BASE64") {
+		t.Fatalf("%q", b.String())
+	}
+}
+
+func TestExternalizeInvalidPhoto(t *testing.T) {
+	invalid := &VCard{FormattedName: "a", Photo: Photo{Encoding: "b", Type: "JPEG", Data: strings.Repeat("!", 100)}}
+	valid := &VCard{FormattedName: "b", Photo: Photo{Encoding: "b", Type: "JPEG", Data: base64.StdEncoding.EncodeToString(make([]byte, 100))}}
+	var b bytes.Buffer
+	var logger captureLogger
+	di := NewDirectoryInfoWriter(&b)
+	di.Logger = &logger
+	di.ExternalizePhoto = func(data []byte, mediaType string) (string, error) {
+		return "http://example.com/b.jpg", nil
+	}
+	invalid.WriteTo(di)
+	valid.WriteTo(di)
+	if di.Err() != nil {
+		t.Fatal(di.Err())
+	}
+	unfolded := strings.Replace(b.String(), "\r\n ", "", -1)
+	if !strings.Contains(unfolded, ":"+invalid.Photo.Data+"\r\n") || !strings.Contains(unfolded, "http://example.com/b.jpg") {
+		t.Fatalf("%q", b.String())
+	}
+	if len(logger.messages) != 1 {
+		t.Fatal(logger.messages)
+	}
+}
+
+func TestBase64Whitespace(t *testing.T) {
+	data := []byte("a photo of some bytes")
+	encoded := base64.StdEncoding.EncodeToString(data)
+	spaced := encoded[:5] + " " + encoded[5:10] + "\t" + encoded[10:20] + " \t " + encoded[20:]
+	for _, s := range []string{spaced, strings.TrimRight(spaced, "=")} {
+		if decoded, err := decodeBase64(s); err != nil || !bytes.Equal(decoded, data) {
+			t.Fatalf("%q: %q %v", s, decoded, err)
+		}
+	}
+	decoded, err := ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, spaceSkipper{strings.NewReader(spaced)}))
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Fatalf("%q %v", decoded, err)
+	}
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nPHOTO;ENCODING=b;TYPE=JPEG:"+spaced+"\r\nEND:VCARD\r\n")
+	if decoded, err := v.Photo.decode(); err != nil || !bytes.Equal(decoded, data) {
+		t.Fatalf("%q %v", decoded, err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "export")
+	if err := os.Mkdir(base, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{filepath.Join(base, "a.jpg"), filepath.Join(dir, "secret.jpg")} {
+		if err := ioutil.WriteFile(name, []byte("\xff\xd8\xff\xe0 photo"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "secret.jpg"), filepath.Join(base, "link.jpg")); err != nil {
+		t.Skip(err)
+	}
+	if err := os.Symlink("a.jpg", filepath.Join(base, "inside.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		uri    string
+		inline bool
+	}{
+		{"a.jpg", true},
+		{"./sub/../a.jpg", true},
+		{"file://" + filepath.ToSlash(filepath.Join(base, "a.jpg")), true},
+		{filepath.ToSlash(filepath.Join(base, "a.jpg")), true},
+		{"inside.jpg", true},
+		{"../secret.jpg", false},
+		{"sub/../../secret.jpg", false},
+		{filepath.ToSlash(filepath.Join(dir, "secret.jpg")), false},
+		{"file://" + filepath.ToSlash(filepath.Join(dir, "secret.jpg")), false},
+		{"link.jpg", false},
+	} {
+		photo := Photo{Value: "uri", Data: test.uri}
+		err := photo.Resolve(base)
+		if test.inline && (err != nil || photo.isURI() || photo.Type != "image/jpeg") {
+			t.Fatalf("%s: %+v %v", test.uri, photo, err)
+		}
+		if !test.inline && (err == nil || !photo.isURI()) {
+			t.Fatalf("%s: %+v", test.uri, photo)
+		}
+	}
+	photo := Photo{Value: "uri", Data: "http://example.com/a.jpg"}
+	if err := photo.Resolve(base); err != nil || photo.Data != "http://example.com/a.jpg" {
+		t.Fatalf("%+v %v", photo, err)
+	}
+}
@@ -0,0 +1,34 @@
+package vcard
+
+import (
+	"strings"
+)
+
+// return the text of a value split by the reader on ',' and ';', e.g: the
+// URI geo:46.772673,-71.282945 or a text with unescaped commas
+func joinValue(value StructuredValue) string {
+	components := make([]string, len(value))
+	for i, v := range value {
+		components[i] = strings.Join(v, ",")
+	}
+	return strings.Join(components, ";")
+}
+
+// return the URI as a value whose ',' and ';' are written unescaped
+func uriValue(uri string) StructuredValue {
+	var value StructuredValue
+	for _, component := range strings.Split(uri, ";") {
+		value = append(value, Value(strings.Split(component, ",")))
+	}
+	return value
+}
+
+// return the position of a geo: URI birthplace, e.g:
+// BIRTHPLACE;VALUE=uri:geo:46.772673,-71.282945
+// false if the birthplace is a text or another URI, e.g: an adr: URI
+func (vcard *VCard) BirthplaceGeo() (*GeoCoord, bool) {
+	if !strings.EqualFold(vcard.BirthplaceValue, "uri") || !strings.HasPrefix(strings.ToLower(vcard.Birthplace), "geo:") {
+		return nil, false
+	}
+	return parseGeo([]string{vcard.Birthplace})
+}
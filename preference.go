@@ -0,0 +1,205 @@
+package vcard
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rank given to properties without preference
+const noPreference = 101
+
+// return the preference rank of a property, lower is preferred.
+// the 4.0 PREF parameter (1 to 100) is used when set, a 3.0 "pref" type
+// ranks 1, otherwise the property has no preference
+func preference(types TypeSet, pref int) int {
+	if pref > 0 {
+		return pref
+	}
+	if types.Has("pref") {
+		return 1
+	}
+	return noPreference
+}
+
+// parse the PREF parameter of a content line, 0 if absent or invalid
+func prefParam(contentLine *ContentLine) int {
+	param, ok := contentLine.Param("PREF")
+	if !ok {
+		return 0
+	}
+	pref, err := strconv.Atoi(strings.TrimSpace(param.GetText()))
+	if err != nil || pref < 1 || pref > 100 {
+		return 0
+	}
+	return pref
+}
+
+// return the preference rank of the telephone, lower is preferred
+func (tel Telephone) Preference() int {
+	return preference(tel.Type, tel.Pref)
+}
+
+// return the preference rank of the email, lower is preferred
+func (email Email) Preference() int {
+	return preference(email.Type, email.Pref)
+}
+
+// return a copy of the telephones sorted by preference, keeping slice order
+// for equal preference
+func sortTelephones(tels []Telephone) []Telephone {
+	sorted := append([]Telephone(nil), tels...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Preference() < sorted[j].Preference()
+	})
+	return sorted
+}
+
+// return a copy of the emails sorted by preference, keeping slice order
+// for equal preference
+func sortEmails(emails []Email) []Email {
+	sorted := append([]Email(nil), emails...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Preference() < sorted[j].Preference()
+	})
+	return sorted
+}
+
+// return the most preferred email, the first one for equal preference,
+// nil if the vcard has no email
+func (vcard *VCard) PreferredEmail() *Email {
+	var preferred *Email
+	for i := range vcard.Emails {
+		if preferred == nil || vcard.Emails[i].Preference() < preferred.Preference() {
+			preferred = &vcard.Emails[i]
+		}
+	}
+	return preferred
+}
+
+// return the most preferred telephone, the first one for equal preference,
+// nil if the vcard has no telephone
+func (vcard *VCard) PreferredTelephone() *Telephone {
+	var preferred *Telephone
+	for i := range vcard.Telephones {
+		if preferred == nil || vcard.Telephones[i].Preference() < preferred.Preference() {
+			preferred = &vcard.Telephones[i]
+		}
+	}
+	return preferred
+}
+
+// return the preferred email address, empty if the vcard has no email
+func (vcard *VCard) PrimaryEmail() string {
+	if email := vcard.PreferredEmail(); email != nil {
+		return email.Address
+	}
+	return ""
+}
+
+// return the preferred telephone number, empty if the vcard has no telephone
+func (vcard *VCard) PrimaryPhone() string {
+	if tel := vcard.PreferredTelephone(); tel != nil {
+		return tel.Number
+	}
+	return ""
+}
+
+// return a copy of the telephones sorted by preference, the most preferred
+// first, telephones of equal preference keeping their order
+func (vcard *VCard) TelephonesByPreference() []Telephone {
+	return sortTelephones(vcard.Telephones)
+}
+
+// the TYPE and PREF parameters of a property, see ConvertTo
+type preferenceParams struct {
+	types *TypeSet
+	pref  *int
+}
+
+// return a copy of the vcard converted to version, e.g: "4.0". the 3.0
+// "pref" type of telephones, emails and IMPPs becomes PREF=1 in 4.0, while
+// before 4.0 the most preferred property of each kind gets the "pref" type
+func (vcard *VCard) ConvertTo(version string) *VCard {
+	c := vcard.Clone()
+	c.Version = version
+	var tels, emails, impps []preferenceParams
+	for i := range c.Telephones {
+		tels = append(tels, preferenceParams{&c.Telephones[i].Type, &c.Telephones[i].Pref})
+	}
+	for i := range c.Emails {
+		emails = append(emails, preferenceParams{&c.Emails[i].Type, &c.Emails[i].Pref})
+	}
+	for i := range c.IMPPs {
+		impps = append(impps, preferenceParams{&c.IMPPs[i].Type, &c.IMPPs[i].Pref})
+	}
+	for _, params := range [][]preferenceParams{tels, emails, impps} {
+		if version == "4.0" {
+			prefTypeToParam(params)
+		} else {
+			prefParamToType(params)
+		}
+	}
+	return c
+}
+
+// return the types to write, before 4.0 which has no PREF parameter the
+// properties of lowest PREF of each kind are typed pref, as by ConvertTo
+func prefTypes(di *DirectoryInfoWriter, name string, types TypeSet, pref int) TypeSet {
+	if pref == 0 || di.version() == "4.0" || types.Has("pref") {
+		return types
+	}
+	if lowest, ok := di.lowestPref[name]; ok && pref != lowest {
+		return types
+	}
+	return append(TypeSet(copyStrings(types)), "pref")
+}
+
+// return the lowest PREF of the telephones, emails and IMPPs of the vcard,
+// by property name, the kinds without PREF are missing
+func (vcard *VCard) lowestPrefs() map[string]int {
+	lowest := make(map[string]int)
+	set := func(name string, pref int) {
+		if l, ok := lowest[name]; pref > 0 && (!ok || pref < l) {
+			lowest[name] = pref
+		}
+	}
+	for _, tel := range vcard.Telephones {
+		set("TEL", tel.Pref)
+	}
+	for _, email := range vcard.Emails {
+		set("EMAIL", email.Pref)
+	}
+	for _, impp := range vcard.IMPPs {
+		set("IMPP", impp.Pref)
+	}
+	return lowest
+}
+
+// replace the "pref" type by PREF=1, an existing PREF is kept
+func prefTypeToParam(params []preferenceParams) {
+	for _, p := range params {
+		if p.types.Has("pref") {
+			p.types.Remove("pref")
+			if *p.pref == 0 {
+				*p.pref = 1
+			}
+		}
+	}
+}
+
+// replace PREF by the "pref" type on the properties of lowest PREF
+func prefParamToType(params []preferenceParams) {
+	best := 0
+	for _, p := range params {
+		if *p.pref > 0 && (best == 0 || *p.pref < best) {
+			best = *p.pref
+		}
+	}
+	for _, p := range params {
+		if best > 0 && *p.pref == best {
+			p.types.Add("pref")
+		}
+		*p.pref = 0
+	}
+}
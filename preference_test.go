@@ -0,0 +1,58 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConvertToWrite(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nN:;a;;;\r\nTEL;TYPE=cell,pref:1234\r\nEMAIL:a@b\r\nEND:VCARD\r\n")
+	var b bytes.Buffer
+	if err := v.ConvertTo("4.0").Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if out := b.String(); !strings.Contains(out, "\r\nVERSION:4.0\r\n") || !strings.Contains(out, "PREF=1") {
+		t.Fatal(out)
+	}
+	v = readCard(t, b.String())
+	b.Reset()
+	if err := v.ConvertTo("3.0").Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if out := b.String(); !strings.Contains(out, "\r\nVERSION:3.0\r\n") || !strings.Contains(out, "TEL;type=cell,pref:1234\r\n") || strings.Contains(out, "PREF=") {
+		t.Fatal(out)
+	}
+}
+
+func TestPrefTypeBefore40(t *testing.T) {
+	v := &VCard{FormattedName: "a", GivenNames: []string{"a"}, Telephones: []Telephone{{Type: TypeSet{"cell"}, Number: "1234", Pref: 1}, {Type: TypeSet{"work"}, Number: "5678", Pref: 2}}, Emails: []Email{{Address: "a@b", Pref: 2}}}
+	var b bytes.Buffer
+	if err := v.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if out := b.String(); !strings.Contains(out, "TEL;type=cell,pref:1234\r\n") || !strings.Contains(out, "TEL;type=work:5678\r\n") || !strings.Contains(out, "EMAIL;type=pref:a@b\r\n") {
+		t.Fatal(out)
+	}
+	if len(v.Telephones[0].Type) != 1 {
+		t.Fatal(v.Telephones[0].Type)
+	}
+}
+
+func TestSortByPreference(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:a\r\nTEL;TYPE=home:1\r\nTEL;TYPE=work;PREF=2:2\r\nTEL;TYPE=cell;PREF=1:3\r\nEMAIL:a@example.com\r\nEMAIL;PREF=1:b@example.com\r\nEND:VCARD\r\n")
+	for _, sort := range []bool{false, true} {
+		var b bytes.Buffer
+		di := NewDirectoryInfoWriter(&b)
+		di.SortByPreference = sort
+		v.WriteTo(di)
+		tels := strings.Index(b.String(), ":3\r\n") < strings.Index(b.String(), ":2\r\n") && strings.Index(b.String(), ":2\r\n") < strings.Index(b.String(), ":1\r\n")
+		emails := strings.Index(b.String(), "b@example.com") < strings.Index(b.String(), "a@example.com")
+		if tels != sort || emails != sort {
+			t.Fatal(sort, b.String())
+		}
+	}
+	if v.Telephones[0].Number != "1" || v.Emails[0].Address != "a@example.com" {
+		t.Fatalf("%+v %+v", v.Telephones, v.Emails)
+	}
+}
@@ -0,0 +1,59 @@
+package vcard
+
+import (
+	"strings"
+)
+
+// a target client whose quirks are followed by WriteProfile
+type Profile int
+
+const (
+	Generic     Profile = iota // no quirk, the writer options are used as is
+	Apple                      // macOS and iOS Contacts
+	Google                     // Google Contacts
+	Thunderbird                // Mozilla Thunderbird
+	Outlook                    // Microsoft Outlook
+)
+
+// return true for the Apple specific properties, e.g: X-ABUID or X-ABLabel
+func isAppleProperty(name string) bool {
+	name = strings.ToUpper(name)
+	return strings.HasPrefix(name, "X-AB") || strings.HasPrefix(name, "X-ADDRESSBOOKSERVER-")
+}
+
+// serialize the vcard for the client of the profile, tuning the version,
+// property name casing, Apple X- properties and the photo encoding.
+// the writer options are restored once the vcard is written
+func (vcard *VCard) WriteProfile(di *DirectoryInfoWriter, profile Profile) {
+	version, upperCase, charsetUTF8 := di.Version, di.upperCase, di.charsetUTF8
+	photoEncoding, skipProperty, appleLabels := di.photoEncoding, di.skipProperty, di.appleLabels
+	switch profile {
+	case Apple:
+		di.Version = "3.0"
+		di.photoEncoding = "b"
+		di.appleLabels = true
+	case Google:
+		di.Version = "3.0"
+		di.photoEncoding = "b"
+		// Google reads the custom labels but no other Apple property
+		di.skipProperty = func(name string) bool {
+			return isAppleProperty(name) && !strings.EqualFold(name, "X-ABLabel")
+		}
+	case Thunderbird:
+		di.Version = "3.0"
+		di.photoEncoding = "b"
+		di.upperCase = true
+		di.charsetUTF8 = true
+		di.skipProperty = isAppleProperty
+	case Outlook:
+		di.Version = "3.0"
+		di.photoEncoding = "BASE64"
+		di.upperCase = true
+		di.charsetUTF8 = true
+		di.skipProperty = isAppleProperty
+	}
+	vcard.WriteTo(di)
+	// the options only, the count of vcards and the error are kept
+	di.Version, di.upperCase, di.charsetUTF8 = version, upperCase, charsetUTF8
+	di.photoEncoding, di.skipProperty, di.appleLabels = photoEncoding, skipProperty, appleLabels
+}
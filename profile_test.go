@@ -0,0 +1,23 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteProfileSeparator(t *testing.T) {
+	var b bytes.Buffer
+	di := NewDirectoryInfoWriter(&b)
+	di.SeparatorBlankLine = true
+	for _, name := range []string{"a", "b"} {
+		v := &VCard{FormattedName: name, GivenNames: []string{name}}
+		v.WriteProfile(di, Outlook)
+	}
+	if di.Err() != nil {
+		t.Fatal(di.Err())
+	}
+	if out := b.String(); !strings.Contains(out, "END:VCARD\r\n\r\nBEGIN:VCARD\r\n") || di.upperCase || di.Version != "" {
+		t.Fatal(out)
+	}
+}
@@ -0,0 +1,155 @@
+package vcard
+
+import (
+	"strconv"
+	"strings"
+)
+
+// a generic view of a vcard property
+type Property struct {
+	Group  string
+	Name   string
+	Params map[string][]string
+	// the values of a single component property, e.g: CATEGORIES,
+	// or one value per component, e.g: for N or ADR
+	Values []string
+}
+
+func newProperty(contentLine *ContentLine) Property {
+	p := Property{Group: contentLine.Group, Name: contentLine.Name}
+	if len(contentLine.Params) > 0 {
+		p.Params = make(map[string][]string, len(contentLine.Params))
+		for _, key := range paramKeys(contentLine) {
+			p.Params[key] = copyStrings(contentLine.Params[key])
+		}
+	}
+	if len(contentLine.Value) == 1 {
+		p.Values = copyStrings(contentLine.Value[0])
+	} else {
+		for _, v := range contentLine.Value {
+			p.Values = append(p.Values, strings.Join(v, ","))
+		}
+	}
+	return p
+}
+
+// return every property of the vcard, modeled and extra, as they would be
+// written in the version of the vcard, BEGIN and END excepted
+func (vcard *VCard) Properties() []Property {
+	var properties []Property
+	vcard.eachContentLine(func(contentLine *ContentLine) {
+		properties = append(properties, newProperty(contentLine))
+	})
+	return properties
+}
+
+// call fn for each content line written for the vcard but BEGIN and END
+func (vcard *VCard) eachContentLine(fn func(contentLine *ContentLine)) {
+	di := &DirectoryInfoWriter{Version: vcard.Version, collect: func(contentLine *ContentLine) {
+		switch contentLine.Name {
+		case "BEGIN", "END":
+		default:
+			fn(contentLine)
+		}
+	}}
+	vcard.WriteTo(di)
+}
+
+// component names of the structured properties, used by FlatMap
+var componentNames = map[string][]string{
+	"N":   {"family", "given", "additional", "prefix", "suffix"},
+	"ADR": {"pobox", "extended", "street", "locality", "region", "code", "country"},
+}
+
+// the types which only qualify another one, e.g: the default intl, postal
+// and parcel of ADR, skipped by FlatMap when another type is set
+var qualifierTypes = map[string]bool{
+	"pref": true, "intl": true, "dom": true, "postal": true, "parcel": true, "voice": true, "internet": true,
+}
+
+// return the type of a property used in the FlatMap keys: the first type,
+// in the order written, other than the qualifier types, e.g: work of
+// intl,postal,parcel,work, or the first qualifier type other than pref
+func flatType(p *ContentLine) string {
+	types, _ := p.Param("TYPE")
+	qualifier := ""
+	for _, t := range types {
+		t = strings.ToLower(t)
+		if !qualifierTypes[t] {
+			return t
+		}
+		if qualifier == "" && t != "pref" {
+			qualifier = t
+		}
+	}
+	return qualifier
+}
+
+// return the properties as a flat map for templating. a key is made of the
+// lowercased property name, its type as returned by flatType, the
+// occurrence number of the name and type, and the component name for N and
+// ADR, e.g: email.home.1, tel.cell.1, adr.work.street, n.family or note.
+// the occurrence number is omitted for the first untyped or structured
+// property
+func (vcard *VCard) FlatMap() map[string]string {
+	flat := make(map[string]string)
+	occurrences := make(map[string]int)
+	vcard.eachContentLine(func(contentLine *ContentLine) {
+		p := newProperty(contentLine)
+		name := strings.ToUpper(p.Name)
+		key := strings.ToLower(name)
+		if t := flatType(contentLine); t != "" {
+			key += "." + t
+		}
+		occurrences[key]++
+		n := occurrences[key]
+		components, structured := componentNames[name]
+		if n > 1 || (key != strings.ToLower(name) && !structured) {
+			key += "." + strconv.Itoa(n)
+		}
+		if !structured {
+			flat[key] = strings.Join(p.Values, ",")
+			return
+		}
+		for i, value := range p.Values {
+			if i < len(components) && value != "" {
+				flat[key+"."+components[i]] = value
+			}
+		}
+	})
+	return flat
+}
+
+// return the first extra property named name, compared case-insensitively,
+// e.g: Extra("X-SKYPE"). the content line may be modified in place
+func (vcard *VCard) Extra(name string) (*ContentLine, bool) {
+	for _, extra := range vcard.Extras {
+		if strings.EqualFold(extra.Name, name) {
+			return extra, true
+		}
+	}
+	return nil, false
+}
+
+// call fn for each telephone, email, URL, jabber ID and IMPP of the vcard,
+// in this order, e.g: fn("EMAIL", []string{"WORK"}, "john@example.com")
+func (vcard *VCard) ForEachContactMethod(fn func(kind string, types []string, value string)) {
+	each := func(kind string, dt DataType, value string) {
+		fn(kind, dt.GetType(), value)
+	}
+	for _, tel := range vcard.Telephones {
+		each("TEL", tel, tel.Number)
+	}
+	for _, email := range vcard.Emails {
+		each("EMAIL", email, email.Address)
+	}
+	for _, url := range vcard.URLs {
+		each("URL", url, url.Value)
+	}
+	for _, jab := range vcard.XJabbers {
+		each("X-JABBER", jab, jab.Address)
+	}
+	for _, impp := range vcard.IMPPs {
+		each("IMPP", impp, impp.URI)
+	}
+}
@@ -0,0 +1,114 @@
+package vcard
+
+import (
+	"strings"
+	"testing"
+)
+
+func readCard(t *testing.T, s string) *VCard {
+	cards, err := UnmarshalAll(strings.NewReader(s))
+	if err != nil || len(cards) != 1 {
+		t.Fatalf("%d cards, %v", len(cards), err)
+	}
+	return cards[0]
+}
+
+func TestPropertiesVersion(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:Team\r\nKIND:group\r\nMEMBER:urn:uuid:1\r\nEXPERTISE:go\r\nEND:VCARD\r\n")
+	names := make(map[string]string)
+	for _, p := range v.Properties() {
+		names[p.Name] = strings.Join(p.Values, ",")
+	}
+	if names["VERSION"] != "4.0" || names["KIND"] != "group" || names["MEMBER"] != "urn:uuid:1" || names["EXPERTISE"] != "go" {
+		t.Fatal(names)
+	}
+	flat := v.FlatMap()
+	if flat["version"] != "4.0" || flat["kind"] != "group" {
+		t.Fatal(flat)
+	}
+	other := readCard(t, "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:Team\r\nKIND:individual\r\nMEMBER:urn:uuid:1\r\nEXPERTISE:go\r\nEND:VCARD\r\n")
+	if v.ContentHash() == other.ContentHash() {
+		t.Fatal("same hash for different KIND")
+	}
+}
+
+func TestTypedParamsCasing(t *testing.T) {
+	var first string
+	for i := 0; i < 20; i++ {
+		di := NewDirectoryInfoReader(strings.NewReader("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nN:a;;;;\r\nTEL;type=cell;X-A=1;TYPE=home;x-a=2:1234\r\nEND:VCARD\r\n"))
+		di.Fidelity = true
+		v := di.ReadVCard()
+		var b strings.Builder
+		if err := v.Write(&b); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			first = b.String()
+			if !strings.Contains(first, "\r\nTEL;type=cell,home;X-A=1,2:1234\r\n") {
+				t.Fatal(first)
+			}
+		} else if b.String() != first {
+			t.Fatal(b.String(), first)
+		}
+	}
+}
+
+func TestContentHashEscaping(t *testing.T) {
+	a := &VCard{FormattedName: "a", Org: []string{"a", "b"}}
+	b := &VCard{FormattedName: "a", Org: []string{"a;b"}}
+	if a.ContentHash() == b.ContentHash() {
+		t.Fatal(`ORG:a;b and ORG:a\;b have the same hash`)
+	}
+	c := &VCard{FormattedName: "a", Org: []string{"a", "b"}}
+	if a.ContentHash() != c.ContentHash() {
+		t.Fatal("equal cards have different hashes")
+	}
+}
+
+func TestContentHashGroups(t *testing.T) {
+	a := readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nN:a;;;;\r\nitem1.TEL:111\r\nitem1.X-ABLabel:x\r\nitem2.TEL:222\r\nitem2.X-ABLabel:y\r\nEND:VCARD\r\n")
+	b := readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nN:a;;;;\r\nitem1.TEL:222\r\nitem1.X-ABLabel:y\r\nitem2.TEL:111\r\nitem2.X-ABLabel:x\r\nEND:VCARD\r\n")
+	if a.ContentHash() != b.ContentHash() {
+		t.Fatal("reordered groups have different hashes")
+	}
+	c := readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nN:a;;;;\r\nitem1.TEL:111\r\nitem1.X-ABLabel:y\r\nitem2.TEL:222\r\nitem2.X-ABLabel:x\r\nEND:VCARD\r\n")
+	if a.ContentHash() == c.ContentHash() {
+		t.Fatal("swapped labels have the same hash")
+	}
+}
+
+func TestFidelityParams(t *testing.T) {
+	di := NewDirectoryInfoReader(strings.NewReader("BEGIN:VCARD\r\nVERSION:3.0\r\nFN;X-A=1:a\r\nN:a;;;;\r\nNOTE;x-b=2;language=fr:note\r\nTITLE:first\r\nTITLE;X-C=3:second\r\nEND:VCARD\r\n"))
+	di.Fidelity = true
+	v := di.ReadVCard()
+	var b strings.Builder
+	if err := v.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	s := b.String()
+	for _, line := range []string{"\r\nFN;X-A=1:a\r\n", "\r\nNOTE;x-b=2;language=fr:note\r\n", "\r\nTITLE:first\r\n", "\r\nTITLE;X-C=3:second\r\n"} {
+		if !strings.Contains(s, line) {
+			t.Fatal(line, s)
+		}
+	}
+	// without fidelity mode the unmodeled parameters are not kept
+	v = readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN;X-A=1:a\r\nN:a;;;;\r\nEND:VCARD\r\n")
+	b.Reset()
+	if err := v.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "X-A") {
+		t.Fatal(b.String())
+	}
+}
+
+func TestFlatMapTypes(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		di := NewDirectoryInfoReader(strings.NewReader("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nN:a;;;;\r\nADR:;;1 Main St;Springfield;;;\r\nTEL;TYPE=voice,cell:111\r\nTEL;type=home;TYPE=work:222\r\nEMAIL;TYPE=internet:a@example.com\r\nEND:VCARD\r\n"))
+		di.Fidelity = true
+		flat := di.ReadVCard().FlatMap()
+		if flat["adr.work.street"] != "1 Main St" || flat["tel.cell.1"] != "111" || flat["tel.home.1"] != "222" || flat["email.internet.1"] != "a@example.com" {
+			t.Fatal(flat)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package vcard
+
+import (
+	"strings"
+	"time"
+)
+
+// REV layouts: ISO 8601 basic and extended formats, with or without time
+var revisionLayouts = []string{
+	"20060102T150405Z",
+	"20060102T150405Z0700",
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"20060102T150405",
+	"2006-01-02T15:04:05",
+	"20060102",
+	"2006-01-02",
+}
+
+// parse a REV timestamp, the returned time is UTC
+func parseRevision(text string) (time.Time, bool) {
+	t, ok := parseTimestamp(text)
+	return t.UTC(), ok
+}
+
+// parse a timestamp in one of the REV layouts, keeping its time zone
+func parseTimestamp(text string) (time.Time, bool) {
+	text = strings.TrimSpace(text)
+	for _, layout := range revisionLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// write the REV property, using the basic format in 4.0 and the
+// extended format in 3.0
+func writeRevision(di *DirectoryInfoWriter, rev time.Time) {
+	layout := "2006-01-02T15:04:05Z"
+	if di.version() == "4.0" {
+		layout = "20060102T150405Z"
+	}
+	di.WriteContentLine(&ContentLine{"", "REV", nil, StructuredValue{Value{rev.UTC().Format(layout)}}, nil, nil})
+}
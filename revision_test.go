@@ -0,0 +1,31 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStampRevision(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nREV:1995-10-31T22:27:10Z\r\nEND:VCARD\r\n")
+	var b bytes.Buffer
+	v.WriteTo(NewDirectoryInfoWriter(&b))
+	if !strings.Contains(b.String(), "\r\nREV:1995-10-31T22:27:10Z\r\n") {
+		t.Fatal(b.String())
+	}
+	before := time.Now().UTC().Truncate(time.Second)
+	for version, layout := range map[string]string{"3.0": "2006-01-02T15:04:05Z", "4.0": "20060102T150405Z"} {
+		b.Reset()
+		di := NewDirectoryInfoWriter(&b)
+		di.Version = version
+		di.StampRevision = true
+		v.WriteTo(di)
+		if v.Revision.Before(before) || v.Revision.Location() != time.UTC {
+			t.Fatal(v.Revision)
+		}
+		if !strings.Contains(b.String(), "\r\nREV:"+v.Revision.Format(layout)+"\r\n") {
+			t.Fatal(version, b.String())
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package vcard
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a UTC offset, e.g: -0800, -08:00 or +05
+var utcOffset = regexp.MustCompile(`^([+-])(\d\d):?(\d\d)?$`)
+
+// return the location of the TZ property, either an IANA time zone name
+// like America/New_York or a fixed offset zone for an UTC offset like -0800
+func (vcard *VCard) Location() (*time.Location, error) {
+	tz := strings.TrimSpace(vcard.TimeZone)
+	if tz == "" {
+		return nil, errors.New("vcard: missing TZ")
+	}
+	if tz == "Z" {
+		return time.UTC, nil
+	}
+	if m := utcOffset.FindStringSubmatch(tz); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes := 0
+		if m[3] != "" {
+			minutes, _ = strconv.Atoi(m[3])
+		}
+		if hours > 14 || minutes > 59 {
+			return nil, errors.New("vcard: invalid TZ " + tz)
+		}
+		offset := hours*3600 + minutes*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(tz, offset), nil
+	}
+	if strings.Contains(tz, "/") || tz == "UTC" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			return loc, nil
+		}
+	}
+	return nil, errors.New("vcard: unknown TZ " + tz)
+}
@@ -0,0 +1,40 @@
+package vcard
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocation(t *testing.T) {
+	for _, test := range []struct {
+		tz     string
+		offset int
+	}{
+		{"-0800", -8 * 3600},
+		{"-08:00", -8 * 3600},
+		{"+05", 5 * 3600},
+		{"+05:30", 5*3600 + 30*60},
+		{"Z", 0},
+		{" +0100 ", 3600},
+	} {
+		loc, err := (&VCard{TimeZone: test.tz}).Location()
+		if err != nil {
+			t.Fatal(test.tz, err)
+		}
+		if _, offset := time.Date(2020, 1, 1, 0, 0, 0, 0, loc).Zone(); offset != test.offset {
+			t.Fatal(test.tz, offset)
+		}
+	}
+	for _, tz := range []string{"", "+15", "-08:60", "Pacific Standard Time", "Nowhere/Unknown"} {
+		if loc, err := (&VCard{TimeZone: tz}).Location(); err == nil {
+			t.Fatal(tz, loc)
+		}
+	}
+	if _, err := time.LoadLocation("America/New_York"); err != nil {
+		t.Skip("no time zone database: ", err)
+	}
+	v := readCard(t, "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:a\r\nTZ:America/New_York\r\nEND:VCARD\r\n")
+	if loc, err := v.Location(); err != nil || loc.String() != "America/New_York" {
+		t.Fatal(loc, err)
+	}
+}
@@ -0,0 +1,35 @@
+package vcard
+
+import (
+	"bufio"
+	"io"
+)
+
+// read the vcards of r one at a time and write the vcard returned by fn to
+// w, e.g: to redact a large file without loading it. a nil vcard returned
+// by fn is dropped, an error stops the transform and is returned, the
+// vcards written before it are flushed to w
+func Transform(r io.Reader, w io.Writer, fn func(*VCard) (*VCard, error)) error {
+	di := NewDirectoryInfoReader(r)
+	bw := bufio.NewWriter(w)
+	dw := NewDirectoryInfoWriter(bw)
+	for vcard := di.ReadVCard(); vcard != nil; vcard = di.ReadVCard() {
+		out, err := fn(vcard)
+		if err != nil {
+			bw.Flush()
+			return err
+		}
+		if out != nil {
+			out.WriteTo(dw)
+			if err := dw.Err(); err != nil {
+				bw.Flush()
+				return err
+			}
+		}
+	}
+	if err := di.Err(); err != nil {
+		bw.Flush()
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,23 @@
+package vcard
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTransformErrorFlushes(t *testing.T) {
+	in := "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:A\r\nEND:VCARD\r\nBEGIN:VCARD\r\nVERSION:3.0\r\nFN:B\r\nEND:VCARD\r\n"
+	stop := errors.New("stop")
+	var b bytes.Buffer
+	err := Transform(strings.NewReader(in), &b, func(vcard *VCard) (*VCard, error) {
+		if vcard.FormattedName == "B" {
+			return nil, stop
+		}
+		return vcard, nil
+	})
+	if err != stop || !strings.HasSuffix(b.String(), "FN:A\r\nN:;;;;\r\nEND:VCARD\r\n") {
+		t.Fatal(err, b.String())
+	}
+}
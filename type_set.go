@@ -0,0 +1,128 @@
+package vcard
+
+import (
+	"strings"
+)
+
+// the TYPE parameter values of a property, e.g: {"HOME", "pref"}
+// type values are case-insensitive
+type TypeSet []string
+
+// return true if the set contains t, compared case-insensitively
+func (ts TypeSet) Has(t string) bool {
+	for _, s := range ts {
+		if strings.EqualFold(s, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// add t to the set if not already present
+func (ts *TypeSet) Add(t string) {
+	if !ts.Has(t) {
+		*ts = append(*ts, t)
+	}
+}
+
+// remove every occurrence of t from the set, compared case-insensitively
+func (ts *TypeSet) Remove(t string) {
+	var kept TypeSet
+	for _, s := range *ts {
+		if !strings.EqualFold(s, t) {
+			kept = append(kept, s)
+		}
+	}
+	*ts = kept
+}
+
+// lowercase and trim each type, drop empty and duplicated types
+func (ts *TypeSet) Normalize() {
+	var normalized TypeSet
+	for _, s := range *ts {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s != "" {
+			normalized.Add(s)
+		}
+	}
+	*ts = normalized
+}
+
+func (addr Address) GetType() []string {
+	return addr.Type
+}
+
+func (addr Address) HasType(t string) bool {
+	return addr.Type.Has(t)
+}
+
+func (tel Telephone) GetType() []string {
+	return tel.Type
+}
+
+func (tel Telephone) HasType(t string) bool {
+	return tel.Type.Has(t)
+}
+
+func (email Email) GetType() []string {
+	return email.Type
+}
+
+func (email Email) HasType(t string) bool {
+	return email.Type.Has(t)
+}
+
+// email types naming the mail system rather than the usage of the address,
+// e.g: EMAIL;TYPE=INTERNET,HOME. X400 and the others are 2.1 types
+var emailTransportTypes = map[string]bool{
+	"INTERNET": true, "X400": true, "AOL": true, "APPLELINK": true,
+	"ATTMAIL": true, "CIS": true, "EWORLD": true, "IBMMAIL": true,
+	"MCIMAIL": true, "POWERSHARE": true, "PRODIGY": true, "TLX": true,
+}
+
+// return the types of the email other than its transport types and pref,
+// e.g: home for INTERNET,HOME,pref
+func (email Email) UsageTypes() []string {
+	var types []string
+	for _, t := range email.Type {
+		if !emailTransportTypes[strings.ToUpper(t)] && !strings.EqualFold(t, "pref") {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// return the transport types of the email, e.g: INTERNET or X400
+func (email Email) TransportTypes() []string {
+	var types []string
+	for _, t := range email.Type {
+		if emailTransportTypes[strings.ToUpper(t)] {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func (jab XJabber) GetType() []string {
+	return jab.Type
+}
+
+func (jab XJabber) HasType(t string) bool {
+	return jab.Type.Has(t)
+}
+
+func (url URL) GetType() []string {
+	return url.Type
+}
+
+func (url URL) HasType(t string) bool {
+	return url.Type.Has(t)
+}
+
+func (impp IMPP) GetType() []string {
+	return impp.Type
+}
+
+func (impp IMPP) HasType(t string) bool {
+	return impp.Type.Has(t)
+}
@@ -0,0 +1,32 @@
+package vcard
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGenerateUID(t *testing.T) {
+	uuid := regexp.MustCompile(`^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	v := &VCard{FormattedName: "a"}
+	var b bytes.Buffer
+	v.WriteTo(NewDirectoryInfoWriter(&b))
+	if v.UID != "" || strings.Contains(b.String(), "UID") {
+		t.Fatal(b.String())
+	}
+	di := NewDirectoryInfoWriter(&b)
+	di.GenerateUID = true
+	v.WriteTo(di)
+	if !uuid.MatchString(v.UID) || !strings.Contains(b.String(), "\r\nUID:"+v.UID+"\r\n") {
+		t.Fatal(v.UID, b.String())
+	}
+	// an existing UID is kept
+	uid := v.UID
+	other := &VCard{FormattedName: "b"}
+	other.WriteTo(di)
+	v.WriteTo(di)
+	if v.UID != uid || !uuid.MatchString(other.UID) || other.UID == uid {
+		t.Fatal(v.UID, other.UID)
+	}
+}
@@ -0,0 +1,69 @@
+package vcard
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// parse the vcards of r, components other than VCARD are skipped
+func UnmarshalAll(r io.Reader) ([]*VCard, error) {
+	di := NewDirectoryInfoReader(r)
+	var ab AddressBook
+	ab.ReadFrom(di)
+	cards := make([]*VCard, len(ab.Contacts))
+	for i := range ab.Contacts {
+		cards[i] = &ab.Contacts[i]
+	}
+	return cards, di.Err()
+}
+
+// same as UnmarshalAll but a malformed vcard, e.g: without END, doesn't
+// stop the parsing. its error is returned along the vcards read, including
+// the malformed one
+func UnmarshalLenient(r io.Reader) ([]*VCard, []error) {
+	di := NewDirectoryInfoReader(r)
+	var ab AddressBook
+	ab.ReadFrom(di)
+	cards := make([]*VCard, len(ab.Contacts))
+	for i := range ab.Contacts {
+		cards[i] = &ab.Contacts[i]
+	}
+	errs := di.Errors()
+	if err := di.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return cards, errs
+}
+
+// parse the vcards of the .vcf files found in the directory tree rooted at
+// path. a file which can't be read doesn't stop the walk, its error is
+// returned along the vcards of the other files
+func ReadDir(path string) ([]*VCard, []error) {
+	var cards []*VCard
+	var errs []error
+	filepath.Walk(path, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		if info.IsDir() || !strings.EqualFold(filepath.Ext(name), ".vcf") {
+			return nil
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		defer f.Close()
+		fileCards, fileErrs := UnmarshalLenient(f)
+		for _, err := range fileErrs {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+		}
+		cards = append(cards, fileCards...)
+		return nil
+	})
+	return cards, errs
+}
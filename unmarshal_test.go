@@ -0,0 +1,43 @@
+package vcard
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestReadDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"a.vcf":         "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nEND:VCARD\r\nBEGIN:VCARD\r\nVERSION:3.0\r\nFN:b\r\nEND:VCARD\r\n",
+		"sub/c.VCF":     "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:c\r\nEND:VCARD\r\n",
+		"broken.vcf":    "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:d\r\n",
+		"notes.txt":     "BEGIN:VCARD\r\nVERSION:3.0\r\nFN:e\r\nEND:VCARD\r\n",
+		"sub/empty.vcf": "",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cards, errs := ReadDir(dir)
+	var names []string
+	for _, card := range cards {
+		names = append(names, card.FormattedName)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "a,b,c,d" {
+		t.Fatal(names)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "broken.vcf") {
+		t.Fatal(errs)
+	}
+	if cards, errs := ReadDir(filepath.Join(dir, "missing")); len(cards) != 0 || len(errs) != 1 {
+		t.Fatal(cards, errs)
+	}
+}
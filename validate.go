@@ -0,0 +1,97 @@
+package vcard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// properties occurring at most once in a 4.0 vcard, VERSION being required
+var singleProperties = []string{"VERSION", "N", "BDAY", "ANNIVERSARY", "GENDER", "PRODID", "REV", "UID", "KIND"}
+
+// count the occurrences of each property in the content lines of the vcard
+func (vcard *VCard) countOccurrences(contentLines []*ContentLine) {
+	vcard.occurrences = make(map[string]int)
+	// the representations of a property sharing an ALTID count once
+	altIDs := make(map[string]bool)
+	for _, contentLine := range contentLines {
+		name := strings.ToUpper(contentLine.Name)
+		if altID, ok := contentLine.Param("ALTID"); ok && altID.GetText() != "" {
+			if altIDs[name+";"+altID.GetText()] {
+				continue
+			}
+			altIDs[name+";"+altID.GetText()] = true
+		}
+		vcard.occurrences[name]++
+	}
+}
+
+// return true if the vcard was read with the property name, even with an
+// empty value, e.g: NOTE: or PHOTO;ENCODING=BASE64:
+func (vcard *VCard) HasProperty(name string) bool {
+	return vcard.occurrences[strings.ToUpper(name)] > 0
+}
+
+// return true for cards which don't describe a person and so don't need
+// a name, i.e: KIND is location or org
+func (vcard *VCard) isNameless() bool {
+	return strings.EqualFold(vcard.Kind, "location") || strings.EqualFold(vcard.Kind, "org")
+}
+
+// return true for cards which don't describe a person,
+// i.e: KIND is org, group or location
+func (vcard *VCard) isNonPerson() bool {
+	return vcard.isNameless() || strings.EqualFold(vcard.Kind, "group")
+}
+
+// return true if any N component is set
+func (vcard *VCard) hasName() bool {
+	for _, names := range [][]string{vcard.FamilyNames, vcard.GivenNames, vcard.AdditionalNames, vcard.HonorificNames, vcard.HonorificSuffixes} {
+		for _, name := range names {
+			if name != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// return the problems making the vcard invalid, nil if the vcard is valid.
+// for a vcard read by ReadFrom, a missing VERSION is reported and, in 4.0,
+// properties occurring more than allowed
+func (vcard *VCard) Validate() []error {
+	return append(vcard.validateName(vcard.Version), vcard.validateCardinality()...)
+}
+
+// return the errors for a missing FN or N, N being required before 4.0
+func (vcard *VCard) validateName(version string) []error {
+	var errs []error
+	if vcard.isNameless() {
+		return errs
+	}
+	if vcard.FormattedName == "" {
+		errs = append(errs, errors.New("vcard: missing FN"))
+	}
+	if version != "4.0" && len(vcard.FamilyNames) == 0 && len(vcard.GivenNames) == 0 {
+		errs = append(errs, errors.New("vcard: missing N"))
+	}
+	return errs
+}
+
+// return the errors for a vcard read without VERSION, and for the properties
+// read more often than allowed in 4.0
+func (vcard *VCard) validateCardinality() []error {
+	var errs []error
+	if vcard.occurrences != nil && vcard.occurrences["VERSION"] == 0 {
+		errs = append(errs, errors.New("vcard: missing VERSION, exactly one required"))
+	}
+	if vcard.Version != "4.0" {
+		return errs
+	}
+	for _, name := range singleProperties {
+		if n := vcard.occurrences[name]; n > 1 {
+			errs = append(errs, fmt.Errorf("vcard: %s occurs %d times, at most once allowed", name, n))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,23 @@
+package vcard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateVersion(t *testing.T) {
+	v := readCard(t, "BEGIN:VCARD\r\nFN:John\r\nN:Doe;John;;;\r\nEND:VCARD\r\n")
+	errs := v.Validate()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "VERSION") {
+		t.Fatal(errs)
+	}
+	v = readCard(t, "BEGIN:VCARD\r\nVERSION:4.0\r\nVERSION:4.0\r\nFN:John\r\nEND:VCARD\r\n")
+	errs = v.Validate()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "VERSION occurs 2 times") {
+		t.Fatal(errs)
+	}
+	v = readCard(t, "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:John\r\nEND:VCARD\r\n")
+	if errs = v.Validate(); errs != nil {
+		t.Fatal(errs)
+	}
+}
@@ -1,9 +1,14 @@
 package vcard
 
 import (
+	"bufio"
+	"fmt"
+	"io"
 	"io/ioutil"
-	"log"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type VCard struct {
@@ -14,48 +19,155 @@ type VCard struct {
 	AdditionalNames   []string
 	HonorificNames    []string
 	HonorificSuffixes []string
+	NameExtra         []string // N components following the honorific suffixes
+	SortAs            []string // 4.0 SORT-AS parameter of N or FN, e.g: Yamada,Taro
+	Alternates        []Name   // FN and N with an ALTID, e.g: in other languages
 	NickNames         []string
+	PhoneticGiven     string // X-PHONETIC-FIRST-NAME, e.g: a Japanese reading
+	PhoneticFamily    string // X-PHONETIC-LAST-NAME
 	Photo             Photo
 	Birthday          string
+	BirthdayScale     string // CALSCALE of BDAY, gregorian by default
+	Anniversary       string
+	AnniversaryScale  string // CALSCALE of ANNIVERSARY, gregorian by default
 	Addresses         []Address
 	Telephones        []Telephone
 	Emails            []Email
-	Title             string
-	Role              string
+	Titles            []string
+	Roles             []string
 	Org               []string
 	Categories        []string
 	Note              string
-	URL               string
-	XJabbers          []XJabber
+	URLs              []URL
+	UID               string
+	Revision          time.Time // REV, zero if unset
+	Kind              string    // e.g: individual, group, org or location
+	Members           []string  // group members, e.g: urn:uuid:...
+	// KIND and MEMBER are read from and written as the macOS server
+	// X-ADDRESSBOOKSERVER-KIND and X-ADDRESSBOOKSERVER-MEMBER properties
+	XAddressBookServer bool
+	Geo                *GeoCoord
+	TimeZone           string // TZ, e.g: America/New_York or -05:00
+	XJabbers           []XJabber
+	IMPPs              []IMPP
+	Expertises         []Interest // RFC 6715 EXPERTISE
+	Hobbies            []Interest // RFC 6715 HOBBY
+	Interests          []Interest // RFC 6715 INTEREST
+	OrgDirectories     []string   // RFC 6715 ORG-DIRECTORY URIs
+	Birthplace         string     // RFC 6474 BIRTHPLACE, a text or a geo: or adr: URI
+	BirthplaceValue    string     // VALUE of BIRTHPLACE, uri for a URI, text by default
+	Deathplace         string     // RFC 6474 DEATHPLACE
+	Deathdate          string     // RFC 6474 DEATHDATE
+	DeathdateScale     string     // CALSCALE of DEATHDATE, gregorian by default
+	ContactURIs        []string   // RFC 8605 CONTACT-URI
+	AgentURI           string     // AGENT;VALUE=uri, an embedded AGENT vcard is kept in Extras
 	// mac specific
-	XABuid    string
-	XABShowAs string
+	XABuid      string
+	XABShowAs   string
+	CustomDates []CustomDate // X-ABDATE, e.g: a labeled anniversary
+	// properties not modeled by the fields above
+	Extras []*ContentLine
+	// LOGO, SOUND or KEY whose data was discarded by the SkipBinaryData
+	// reader option, kept in Extras without value and not written back
+	SkippedBinary []string
+	// occurrences of each property, counted when read
+	occurrences map[string]int
+	// LABEL properties read, matched to the addresses once the card is read
+	deliveryLabels []*ContentLine
+	// X-MS-OL-DEFAULT-POSTAL-ADDRESS read, matched to an address once the
+	// card is read
+	defaultPostal *ContentLine
+	// N left out by Redact, not written back empty in 4.0
+	nameRedacted bool
+	// content lines read in fidelity mode by uppercased name, giving their
+	// parameters to the modeled properties written, see restoreParams
+	sources map[string][]*ContentLine
 }
 
-func displayStrings(ss []string) (display string) {
-	for _, s := range ss {
-		display += s + ", "
-	}
-	return display
+func displayStrings(ss []string) string {
+	return strings.Join(ss, ", ")
 }
 
-func (v VCard) String() (s string) {
-	s = "VCard version: " + v.Version + "\n"
-	s += "FormattedName:" + v.FormattedName + "\n"
-	s += "FamilyNames:" + displayStrings(v.FamilyNames) + "\n"
-	s += "GivenNames:" + displayStrings(v.GivenNames) + "\n"
-	s += "AdditionalNames:" + displayStrings(v.AdditionalNames) + "\n"
-	return s
+func (v VCard) String() string {
+	var b strings.Builder
+	b.WriteString("VCard version: " + v.Version + "\n")
+	b.WriteString("FormattedName:" + v.FormattedName + "\n")
+	b.WriteString("FamilyNames:" + displayStrings(v.FamilyNames) + "\n")
+	b.WriteString("GivenNames:" + displayStrings(v.GivenNames) + "\n")
+	b.WriteString("AdditionalNames:" + displayStrings(v.AdditionalNames) + "\n")
+	return b.String()
 }
 
 type Photo struct {
 	Encoding string
-	Type     string
+	Type     string // e.g: JPEG in 3.0 or image/jpeg in 4.0
 	Value    string
 	Data     string
+	Skipped  bool // data discarded by the SkipBinaryData reader option
+	// where the data was folded when read in fidelity mode, see ContentLine
+	Folds []int
+}
+
+// return the components of the SORT-AS parameter, e.g: "Yamada,Taro"
+// quoted as a single value, nil if absent
+func sortAsParam(contentLine *ContentLine) []string {
+	param, ok := contentLine.Param("SORT-AS")
+	if !ok {
+		return nil
+	}
+	var sortAs []string
+	for _, value := range param {
+		for _, s := range strings.Split(value, ",") {
+			sortAs = append(sortAs, strings.TrimSpace(s))
+		}
+	}
+	return sortAs
+}
+
+// return the CALSCALE parameter of a date property, gregorian if absent
+func calendarScale(contentLine *ContentLine) string {
+	if param, ok := contentLine.Param("CALSCALE"); ok && param.GetText() != "" {
+		return param.GetText()
+	}
+	return "gregorian"
+}
+
+// return the parameters of a date property with the calendar scale,
+// nil for the default gregorian calendar and before 4.0 which has no
+// CALSCALE parameter
+func calendarParams(di *DirectoryInfoWriter, calendar string) map[string]Value {
+	if di.version() != "4.0" || calendar == "" || strings.EqualFold(calendar, "gregorian") {
+		return nil
+	}
+	return map[string]Value{"CALSCALE": Value{calendar}}
+}
+
+// convert a 3.0 type token like "JPEG" to a MIME type like "image/jpeg",
+// MIME types are returned lowercased
+func mediaTypeFromToken(t string) string {
+	t = strings.ToLower(strings.TrimSpace(t))
+	if t == "" || strings.Contains(t, "/") {
+		return t
+	}
+	switch t {
+	case "jpg":
+		t = "jpeg"
+	case "tif":
+		t = "tiff"
+	}
+	return "image/" + t
 }
 
-func defaultAddressTypes() (types []string) {
+// convert a MIME type like "image/jpeg" to a 3.0 type token like "JPEG",
+// type tokens are returned unchanged
+func tokenFromMediaType(t string) string {
+	if i := strings.Index(t, "/"); i != -1 {
+		return strings.ToUpper(t[i+1:])
+	}
+	return t
+}
+
+func defaultAddressTypes() (types TypeSet) {
 	return []string{"Intl", "Postal", "Parcel", "Work"}
 }
 
@@ -65,7 +177,7 @@ type DataType interface {
 }
 
 type Address struct {
-	Type            []string // default is Intl,Postal,Parcel,Work
+	Type            TypeSet // default is Intl,Postal,Parcel,Work
 	Label           string
 	PostOfficeBox   string
 	ExtendedAddress string
@@ -74,21 +186,84 @@ type Address struct {
 	Region          string // e.g: state or province
 	PostalCode      string
 	CountryName     string
+	CountryCode     string       // 4.0 CC parameter, ISO 3166 code, e.g: US
+	Extra           []string     // components following the country name
+	Geo             *GeoCoord    // 4.0 GEO parameter
+	Group           string       // e.g: item1, shared with an X-ABADR line
+	DefaultPostal   bool         // Outlook mailing address, X-MS-OL-DEFAULT-POSTAL-ADDRESS
+	source          *ContentLine // set in fidelity mode
 }
 
 type Telephone struct {
-	Type   []string
+	Type   TypeSet
 	Number string
+	Pref   int          // PREF parameter, 0 if unset
+	Group  string       // e.g: item1
+	Label  string       // custom label from X-ABLabel
+	source *ContentLine // set in fidelity mode
+	uri    bool         // read as a tel: URI, written back as such in 4.0
+	// X-ABLabel read wrapped in _$!< and >!$_, written back wrapped
+	labelWrapped bool
 }
 
 type Email struct {
-	Type    []string
-	Address string
+	Type     TypeSet
+	Address  string
+	Original string       // address before Normalize, empty if unchanged
+	Pref     int          // PREF parameter, 0 if unset
+	Group    string       // e.g: item1
+	Label    string       // custom label from X-ABLabel
+	source   *ContentLine // set in fidelity mode
+	uri      bool         // read as a mailto: URI, written back as such in 4.0
+	// X-ABLabel read wrapped in _$!< and >!$_, written back wrapped
+	labelWrapped bool
+}
+
+type URL struct {
+	Type   TypeSet
+	Value  string
+	Group  string       // e.g: item1
+	Label  string       // custom label from X-ABLabel
+	source *ContentLine // set in fidelity mode
+	// X-ABLabel read wrapped in _$!< and >!$_, written back wrapped
+	labelWrapped bool
 }
 
 type XJabber struct {
-	Type    []string
+	Type    TypeSet
 	Address string
+	Group   string       // e.g: item1
+	Label   string       // custom label from X-ABLabel
+	source  *ContentLine // set in fidelity mode
+	// X-ABLabel read wrapped in _$!< and >!$_, written back wrapped
+	labelWrapped bool
+}
+
+// an instant messaging address, e.g: IMPP;X-SERVICE-TYPE=Jabber:xmpp:a@b.c
+type IMPP struct {
+	Type    TypeSet
+	URI     string
+	Service string       // X-SERVICE-TYPE parameter, the URI scheme if absent
+	Pref    int          // PREF parameter, 0 if unset
+	Group   string       // e.g: item1
+	Label   string       // custom label from X-ABLabel
+	source  *ContentLine // set in fidelity mode
+	outlook bool         // read from X-MS-IMADDRESS, written back as such
+	// X-ABLabel read wrapped in _$!< and >!$_, written back wrapped
+	labelWrapped bool
+}
+
+// a date with a custom label, written by Apple Address Book as:
+//
+//	item1.X-ABDATE;type=pref:2004-06-12
+//	item1.X-ABLabel:_$!<Anniversary>!$_
+type CustomDate struct {
+	Type  TypeSet
+	Date  string
+	Group string // e.g: item1
+	Label string // custom label from X-ABLabel
+	// X-ABLabel read wrapped in _$!< and >!$_, written back wrapped
+	labelWrapped bool
 }
 
 const ( // Constant define address information index in directory information StructuredValue
@@ -115,270 +290,854 @@ func getValueFromContentLine(index int, contentLine *ContentLine) ([]string, str
 	maxIndex := len(contentLine.Value) - 1
 	if maxIndex >= index {
 		text := contentLine.Value[index].GetText()
-
-		if strings.ToLower(contentLine.Params["ENCODING"].GetText()) == "quoted-printable" {
+		encoding, _ := contentLine.Param("ENCODING")
+		switch strings.ToUpper(encoding.GetText()) {
+		case "QUOTED-PRINTABLE":
 			bytes, err := ioutil.ReadAll(newQuotedPrintableReader(strings.NewReader(text)))
-			if err != nil {
-				return contentLine.Value[index], text
+			if err == nil {
+				text = string(bytes)
 			}
-			return contentLine.Value[index], string(bytes)
-		} else {
-			return contentLine.Value[index], text
+		case "8BIT", "7BIT", "BINARY":
+			// raw text, nothing to decode
 		}
+		return contentLine.Value[index], text
 	}
 	return nil, ""
 }
 
+// return the text of the components from index on, i.e: the components
+// of a structured value beyond the ones defined by the RFC
+func getExtraFromContentLine(index int, contentLine *ContentLine) (extra []string) {
+	for i := index; i < len(contentLine.Value); i++ {
+		_, text := getValueFromContentLine(i, contentLine)
+		extra = append(extra, text)
+	}
+	return extra
+}
+
 func (vcard *VCard) ReadFrom(di *DirectoryInfoReader) {
-	contentLine := di.ReadContentLine()
-	for contentLine != nil {
-		switch contentLine.Name {
-		case "VERSION":
-			fallthrough
-		case "version":
+	// some producers don't put VERSION first, the content lines of the card
+	// are buffered so the version is known before interpreting them
+	var contentLines []*ContentLine
+	di.stats.Cards++
+	ended := false
+	for contentLine := di.ReadContentLine(); contentLine != nil; contentLine = di.ReadContentLine() {
+		if strings.EqualFold(contentLine.Name, "END") && contentLine.ComponentName() == "VCARD" {
+			ended = true
+			break
+		}
+		// a vcard missing its END, the line begins the next vcard
+		if strings.EqualFold(contentLine.Name, "BEGIN") && contentLine.ComponentName() == "VCARD" {
+			di.unread = contentLine
+			break
+		}
+		if strings.EqualFold(contentLine.Name, "VERSION") {
 			vcard.Version = contentLine.Value.GetText()
-		case "END":
-			fallthrough
-		case "end":
-			if contentLine.Value.GetText() == "VCARD" {
+		} else if strings.EqualFold(contentLine.Name, "KIND") || strings.EqualFold(contentLine.Name, "X-ADDRESSBOOKSERVER-KIND") {
+			vcard.Kind = contentLine.Value.GetText()
+		}
+		contentLines = append(contentLines, contentLine)
+	}
+	vcard.countOccurrences(contentLines)
+	labels := make(map[string]string)
+	for _, contentLine := range contentLines {
+		if vcard.Version == "2.1" {
+			normalizeBareParameters(contentLine)
+		}
+		if encoding, ok := contentLine.Param("ENCODING"); ok && strings.EqualFold(encoding.GetText(), "QUOTED-PRINTABLE") {
+			di.stats.QuotedPrintable++
+		}
+		if di.DefaultCharset != "" {
+			di.transcode(contentLine)
+		}
+		if di.RepairMojibake {
+			repairContentLine(contentLine)
+		}
+		if di.handleProperty(contentLine, vcard) {
+			continue
+		}
+		if di.Fidelity {
+			vcard.addSource(contentLine)
+		}
+		vcard.readContentLine(di, contentLine, labels)
+		if di.SkipBinaryData && binaryProperties[strings.ToUpper(contentLine.Name)] && !isURIValue(contentLine.Params) {
+			if strings.EqualFold(contentLine.Name, "PHOTO") {
+				vcard.Photo.Skipped = true
+			} else {
+				vcard.SkippedBinary = append(vcard.SkippedBinary, strings.ToUpper(contentLine.Name))
+			}
+		}
+	}
+	vcard.complete(di, labels)
+	if !ended && !di.implicit {
+		di.errs = append(di.errs, fmt.Errorf("vcard: vcard %d %q has no END", di.stats.Cards, vcard.FormattedName))
+	}
+}
+
+func (vcard *VCard) readContentLine(di *DirectoryInfoReader, contentLine *ContentLine, labels map[string]string) {
+	switch strings.ToUpper(contentLine.Name) {
+	case "VERSION":
+		vcard.Version = contentLine.Value.GetText()
+	case "FN":
+		if vcard != nil {
+			// the first representation of the name is kept in FormattedName
+			if alt := vcard.altName(contentLine); alt != nil {
+				alt.FormattedName = contentLine.Value.GetText()
+				if vcard.FormattedName != "" {
+					return
+				}
+				alt.primaryFN = true
+			}
+			vcard.FormattedName = contentLine.Value.GetText()
+			if sortAs := sortAsParam(contentLine); sortAs != nil && vcard.SortAs == nil {
+				vcard.SortAs = sortAs
+			}
+		}
+	case "N":
+		// NOTE not all vcard names contain all fields, some have more fields
+		contentLineLength := len(contentLine.Value)
+		if alt := vcard.altName(contentLine); alt != nil {
+			alt.FamilyNames, _ = getValueFromContentLine(familyNames, contentLine)
+			alt.GivenNames, _ = getValueFromContentLine(givenNames, contentLine)
+			alt.AdditionalNames, _ = getValueFromContentLine(additionalNames, contentLine)
+			alt.HonorificNames, _ = getValueFromContentLine(honorificPrefixes, contentLine)
+			alt.HonorificSuffixes, _ = getValueFromContentLine(honorificSuffixes, contentLine)
+			if vcard.hasName() {
 				return
 			}
-		case "FN":
-			fallthrough
-		case "fn":
-			if vcard != nil {
-				vcard.FormattedName = contentLine.Value.GetText()
+			alt.primaryN = contentLineLength > 0
+		}
+		if contentLineLength > 0 {
+			vcard.FamilyNames, _ = getValueFromContentLine(familyNames, contentLine)
+			vcard.GivenNames, _ = getValueFromContentLine(givenNames, contentLine)
+			vcard.AdditionalNames, _ = getValueFromContentLine(additionalNames, contentLine)
+			vcard.HonorificNames, _ = getValueFromContentLine(honorificPrefixes, contentLine)
+			vcard.HonorificSuffixes, _ = getValueFromContentLine(honorificSuffixes, contentLine)
+			vcard.NameExtra = getExtraFromContentLine(nameSize, contentLine)
+			// the SORT-AS of N wins over the one of FN
+			if sortAs := sortAsParam(contentLine); sortAs != nil {
+				vcard.SortAs = sortAs
 			}
-		case "N":
-			fallthrough
-		case "n":
-			// NOTE not all vcard names contain all fields, some have more fields
-			contentLineLength := len(contentLine.Value)
-			if contentLineLength > 0 {
-				vcard.FamilyNames, _ = getValueFromContentLine(familyNames, contentLine)
-				vcard.GivenNames, _ = getValueFromContentLine(givenNames, contentLine)
-				vcard.AdditionalNames, _ = getValueFromContentLine(additionalNames, contentLine)
-				vcard.HonorificNames, _ = getValueFromContentLine(honorificPrefixes, contentLine)
-				vcard.HonorificSuffixes, _ = getValueFromContentLine(honorificSuffixes, contentLine)
-				if contentLineLength > nameSize {
-					log.Printf("N data has more fields: %d\n", contentLineLength)
-				} else if contentLineLength < nameSize {
-					log.Printf("N data has less fields: %d\n", contentLineLength)
-				}
+			if vcard.isNameless() {
+				// no person name expected for location and org cards
+			} else if contentLineLength > nameSize {
+				di.logf("N data has more fields: %d\n", contentLineLength)
+			} else if contentLineLength < nameSize {
+				di.logf("N data has less fields: %d\n", contentLineLength)
+			}
+		} else if !vcard.isNameless() {
+			di.logf("Error: N data has no field\n")
+		}
+	case "X-PHONETIC-FIRST-NAME":
+		vcard.PhoneticGiven = contentLine.Value.GetText()
+	case "X-PHONETIC-LAST-NAME":
+		vcard.PhoneticFamily = contentLine.Value.GetText()
+	case "NICKNAME":
+		vcard.NickNames = contentLine.Value.GetTextList()
+	case "PHOTO":
+		encoding, _ := contentLine.Param("ENCODING")
+		vcard.Photo.Encoding = encoding.GetText()
+		if mediaType, ok := contentLine.Param("MEDIATYPE"); ok {
+			vcard.Photo.Type = mediaTypeFromToken(mediaType.GetText())
+		} else {
+			photoType, _ := contentLine.Param("TYPE")
+			vcard.Photo.Type = photoType.GetText()
+		}
+		value, _ := contentLine.Param("VALUE")
+		vcard.Photo.Value = value.GetText()
+		vcard.Photo.Data = contentLine.Value.GetText()
+		if photo, ok := dataURIPhoto(contentLine.Value); ok {
+			vcard.Photo = photo
+		} else if vcard.Version == "4.0" && vcard.Photo.Value == "" && !vcard.Photo.isBase64() && vcard.Photo.Data != "" {
+			// a 4.0 PHOTO is a URI by default, inline data being a data URI
+			vcard.Photo.Value = "uri"
+		}
+		vcard.Photo.Folds = contentLine.Folds
+	case "BDAY":
+		vcard.Birthday = contentLine.Value.GetText()
+		vcard.BirthdayScale = calendarScale(contentLine)
+		if !di.LenientBirthday && !isDateValue(contentLine) {
+			di.logf("Invalid BDAY: %s\n", vcard.Birthday)
+		}
+	case "ANNIVERSARY", "X-ANNIVERSARY":
+		vcard.Anniversary = contentLine.Value.GetText()
+		vcard.AnniversaryScale = calendarScale(contentLine)
+	case "ADR":
+		// NOTE not all vcard addresses contain all fields, some have more fields
+		contentLineLength := len(contentLine.Value)
+		if contentLineLength > 0 {
+			var address Address
+			if param, ok := contentLine.Param("TYPE"); ok {
+				address.Type = TypeSet(param)
 			} else {
-				log.Printf("Error: N data has no field\n")
+				address.Type = defaultAddressTypes()
+			}
+			address.Group = contentLine.Group
+			if contentLine.ParamOrder != nil {
+				address.source = contentLine
+			}
+			_, address.PostOfficeBox = getValueFromContentLine(postOfficeBox, contentLine)
+			_, address.ExtendedAddress = getValueFromContentLine(extendedAddress, contentLine)
+			_, address.Street = getValueFromContentLine(street, contentLine)
+			_, address.Locality = getValueFromContentLine(locality, contentLine)
+			_, address.Region = getValueFromContentLine(region, contentLine)
+			_, address.PostalCode = getValueFromContentLine(postalCode, contentLine)
+			_, address.CountryName = getValueFromContentLine(countryName, contentLine)
+			address.Extra = getExtraFromContentLine(addressSize, contentLine)
+			if param, ok := contentLine.Param("LABEL"); ok {
+				address.Label = labelParamUnescaper.Replace(param.GetText())
 			}
-		case "NICKNAME":
-			fallthrough
-		case "nickname":
-			vcard.NickNames = contentLine.Value.GetTextList()
-		case "PHOTO":
-			fallthrough
-		case "photo":
-			vcard.Photo.Encoding = contentLine.Params["ENCODING"].GetText()
-			vcard.Photo.Type = contentLine.Params["TYPE"].GetText()
-			vcard.Photo.Value = contentLine.Params["VALUE"].GetText()
-			vcard.Photo.Data = contentLine.Value.GetText()
-		case "BDAY":
-			fallthrough
-		case "bday":
-			vcard.Birthday = contentLine.Value.GetText()
-		case "ADR":
-			fallthrough
-		case "adr":
-			// NOTE not all vcard addresses contain all fields, some have more fields
-			contentLineLength := len(contentLine.Value)
-			if contentLineLength > 0 {
-				var address Address
-				if param, ok := contentLine.Params["TYPE"]; ok {
-					address.Type = param
+			if param, ok := contentLine.Param("CC"); ok {
+				address.CountryCode = param.GetText()
+			}
+			if param, ok := contentLine.Param("GEO"); ok {
+				if geo, ok := parseGeo(param); ok {
+					address.Geo = geo
 				} else {
-					address.Type = defaultAddressTypes()
-				}
-				_, address.PostOfficeBox = getValueFromContentLine(postOfficeBox, contentLine)
-				_, address.ExtendedAddress = getValueFromContentLine(extendedAddress, contentLine)
-				_, address.Street = getValueFromContentLine(street, contentLine)
-				_, address.Locality = getValueFromContentLine(locality, contentLine)
-				_, address.Region = getValueFromContentLine(region, contentLine)
-				_, address.PostalCode = getValueFromContentLine(postalCode, contentLine)
-				_, address.CountryName = getValueFromContentLine(countryName, contentLine)
-				vcard.Addresses = append(vcard.Addresses, address)
-				if contentLineLength > addressSize {
-					log.Printf("ADR data has more fields: %d\n", contentLineLength)
-				} else if contentLineLength < addressSize {
-					log.Printf("ADR data has less fields: %d\n", contentLineLength)
+					di.logf("Invalid ADR GEO: %s\n", param)
 				}
-			} else {
-				log.Printf("Error: ADR data has no field\n")
 			}
-		case "X-ABUID":
-			fallthrough
-		case "x-abuid":
-			vcard.XABuid = contentLine.Value.GetText()
-		case "TEL":
-			fallthrough
-		case "tel":
-			var tel Telephone
-			if param, ok := contentLine.Params["type"]; ok {
-				tel.Type = param
-			} else {
-				tel.Type = []string{"voice"}
+			vcard.Addresses = append(vcard.Addresses, address)
+			if contentLineLength > addressSize {
+				di.logf("ADR data has more fields: %d\n", contentLineLength)
+			} else if contentLineLength < addressSize {
+				di.logf("ADR data has less fields: %d\n", contentLineLength)
+			}
+		} else {
+			di.logf("Error: ADR data has no field\n")
+		}
+	case "LABEL":
+		vcard.deliveryLabels = append(vcard.deliveryLabels, contentLine)
+	case "X-ABUID":
+		vcard.XABuid = contentLine.Value.GetText()
+	case "TEL":
+		var tel Telephone
+		if param, ok := contentLine.Param("TYPE"); ok {
+			tel.Type = TypeSet(param)
+		} else {
+			tel.Type = []string{"voice"}
+		}
+		tel.Pref = prefParam(contentLine)
+		tel.Group = contentLine.Group
+		if contentLine.ParamOrder != nil {
+			tel.source = contentLine
+		}
+		for _, number := range di.values(contentLine) {
+			tel.Number = number
+			if isURIValue(contentLine.Params) {
+				tel.Number, tel.uri = trimScheme(tel.Number, "tel:")
 			}
-			tel.Number = contentLine.Value.GetText()
 			vcard.Telephones = append(vcard.Telephones, tel)
-		case "EMAIL":
-			fallthrough
-		case "email":
-			var email Email
-			if param, ok := contentLine.Params["type"]; ok {
-				email.Type = param
-			} else {
-				email.Type = []string{"HOME"}
+		}
+	case "EMAIL":
+		var email Email
+		if param, ok := contentLine.Param("TYPE"); ok {
+			email.Type = TypeSet(param)
+		} else {
+			email.Type = []string{"HOME"}
+		}
+		email.Pref = prefParam(contentLine)
+		email.Group = contentLine.Group
+		if contentLine.ParamOrder != nil {
+			email.source = contentLine
+		}
+		for _, address := range di.values(contentLine) {
+			email.Address = address
+			if isURIValue(contentLine.Params) {
+				email.Address, email.uri = trimScheme(email.Address, "mailto:")
 			}
-			email.Address = contentLine.Value.GetText()
 			vcard.Emails = append(vcard.Emails, email)
-		case "TITLE":
-			fallthrough
-		case "title":
-			vcard.Title = contentLine.Value.GetText()
-		case "ROLE":
-			fallthrough
-		case "role":
-			vcard.Role = contentLine.Value.GetText()
-		case "ORG":
-			fallthrough
-		case "org":
-			vcard.Org = contentLine.Value.GetTextList()
-		case "CATEGORIES":
-			fallthrough
-		case "categories":
-			vcard.Categories = contentLine.Value.GetTextList()
-		case "NOTE":
-			fallthrough
-		case "note":
-			vcard.Note = contentLine.Value.GetText()
-		case "URL":
-			fallthrough
-		case "url":
-			vcard.URL = contentLine.Value.GetText()
-		case "X-JABBER":
-			fallthrough
-		case "x-jabber":
-			fallthrough
-		case "X-GTALK":
-			fallthrough
-		case "x-gtalk":
-			var jabber XJabber
-			if param, ok := contentLine.Params["type"]; ok {
-				jabber.Type = param
-			} else {
-				jabber.Type = []string{"HOME"}
-			}
-			jabber.Address = contentLine.Value.GetText()
-			vcard.XJabbers = append(vcard.XJabbers, jabber)
-		case "X-ABShowAs":
-			vcard.XABShowAs = contentLine.Value.GetText()
-		/*case "X-ABLabel":
-		case "X-ABADR":
-			// ignore*/
-		default:
-			log.Printf("Not read %s, %s: %s\n", contentLine.Group, contentLine.Name, contentLine.Value)
 		}
-		contentLine = di.ReadContentLine()
+	case "TITLE":
+		vcard.Titles = append(vcard.Titles, contentLine.Value.GetText())
+	case "ROLE":
+		vcard.Roles = append(vcard.Roles, contentLine.Value.GetText())
+	case "ORG":
+		vcard.Org = contentLine.Value.GetTextList()
+	case "CATEGORIES":
+		vcard.Categories = contentLine.Value.GetTextList()
+	case "NOTE":
+		vcard.Note = contentLine.Value.GetText()
+	case "URL":
+		var url URL
+		if param, ok := contentLine.Param("TYPE"); ok {
+			url.Type = TypeSet(param)
+		}
+		url.Value = contentLine.Value.GetText()
+		url.Group = contentLine.Group
+		if contentLine.ParamOrder != nil {
+			url.source = contentLine
+		}
+		vcard.URLs = append(vcard.URLs, url)
+	case "KIND":
+		vcard.Kind = contentLine.Value.GetText()
+	case "MEMBER":
+		vcard.Members = append(vcard.Members, contentLine.Value.GetText())
+	case "X-ADDRESSBOOKSERVER-KIND":
+		vcard.Kind = contentLine.Value.GetText()
+		vcard.XAddressBookServer = true
+	case "X-ADDRESSBOOKSERVER-MEMBER":
+		vcard.Members = append(vcard.Members, contentLine.Value.GetText())
+		vcard.XAddressBookServer = true
+	case "GEO":
+		if geo, ok := parseGeo(contentLine.Value.GetTextList()); ok {
+			vcard.Geo = geo
+		} else {
+			di.logf("Invalid GEO: %s\n", contentLine.Value)
+		}
+	case "TZ":
+		vcard.TimeZone = contentLine.Value.GetText()
+	case "UID":
+		vcard.UID = contentLine.Value.GetText()
+	case "REV":
+		if rev, ok := parseRevision(contentLine.Value.GetText()); ok {
+			vcard.Revision = rev
+		} else {
+			di.logf("Invalid REV: %s\n", contentLine.Value)
+			vcard.Extras = append(vcard.Extras, contentLine)
+		}
+	case "X-JABBER", "X-GTALK":
+		var jabber XJabber
+		if param, ok := contentLine.Param("TYPE"); ok {
+			jabber.Type = TypeSet(param)
+		} else {
+			jabber.Type = []string{"HOME"}
+		}
+		jabber.Address = contentLine.Value.GetText()
+		jabber.Group = contentLine.Group
+		if contentLine.ParamOrder != nil {
+			jabber.source = contentLine
+		}
+		vcard.XJabbers = append(vcard.XJabbers, jabber)
+	case "IMPP":
+		var impp IMPP
+		if param, ok := contentLine.Param("TYPE"); ok {
+			impp.Type = TypeSet(param)
+		}
+		impp.URI = contentLine.Value.GetText()
+		if param, ok := contentLine.Param("X-SERVICE-TYPE"); ok {
+			impp.Service = param.GetText()
+		} else {
+			impp.Service = uriScheme(impp.URI)
+		}
+		impp.Pref = prefParam(contentLine)
+		impp.Group = contentLine.Group
+		if contentLine.ParamOrder != nil {
+			impp.source = contentLine
+		}
+		vcard.IMPPs = append(vcard.IMPPs, impp)
+	case "X-MS-IMADDRESS":
+		// Outlook instant messaging address, e.g: someone@example.com
+		impp := IMPP{URI: contentLine.Value.GetText(), Group: contentLine.Group, outlook: true}
+		impp.Service = uriScheme(impp.URI)
+		vcard.IMPPs = append(vcard.IMPPs, impp)
+	case "X-MS-OL-DEFAULT-POSTAL-ADDRESS":
+		vcard.defaultPostal = contentLine
+	case "EXPERTISE":
+		vcard.Expertises = append(vcard.Expertises, readInterest(contentLine))
+	case "HOBBY":
+		vcard.Hobbies = append(vcard.Hobbies, readInterest(contentLine))
+	case "INTEREST":
+		vcard.Interests = append(vcard.Interests, readInterest(contentLine))
+	case "ORG-DIRECTORY":
+		vcard.OrgDirectories = append(vcard.OrgDirectories, contentLine.Value.GetText())
+	case "BIRTHPLACE":
+		value, _ := contentLine.Param("VALUE")
+		vcard.BirthplaceValue = value.GetText()
+		vcard.Birthplace = joinValue(contentLine.Value)
+	case "DEATHPLACE":
+		vcard.Deathplace = contentLine.Value.GetText()
+	case "DEATHDATE":
+		vcard.Deathdate = contentLine.Value.GetText()
+		vcard.DeathdateScale = calendarScale(contentLine)
+	case "CONTACT-URI":
+		vcard.ContactURIs = append(vcard.ContactURIs, contentLine.Value.GetText())
+	case "AGENT":
+		if isURIValue(contentLine.Params) {
+			vcard.AgentURI = contentLine.Value.GetText()
+		} else {
+			vcard.Extras = append(vcard.Extras, contentLine)
+		}
+	case "RELATED":
+		// the 4.0 replacement of AGENT
+		if types, ok := contentLine.Param("TYPE"); ok && TypeSet(types).Has("agent") && vcard.AgentURI == "" {
+			vcard.AgentURI = contentLine.Value.GetText()
+		} else {
+			vcard.Extras = append(vcard.Extras, contentLine)
+		}
+	case "X-ABSHOWAS":
+		vcard.XABShowAs = contentLine.Value.GetText()
+	case "X-ABLABEL":
+		if contentLine.Group != "" {
+			labels[contentLine.Group] = contentLine.Value.GetText()
+		} else {
+			vcard.Extras = append(vcard.Extras, contentLine)
+		}
+	case "X-ABDATE":
+		var date CustomDate
+		if param, ok := contentLine.Param("TYPE"); ok {
+			date.Type = TypeSet(param)
+		}
+		date.Date = contentLine.Value.GetText()
+		date.Group = contentLine.Group
+		vcard.CustomDates = append(vcard.CustomDates, date)
+	case "X-ABADR":
+		// country code of the address of the same group, e.g: item1.X-ABADR:us,
+		// kept as is
+		vcard.Extras = append(vcard.Extras, contentLine)
+	default:
+		di.stats.Skipped++
+		di.logf("Not read %s, %s: %s\n", contentLine.Group, contentLine.Name, contentLine.Value)
+		vcard.Extras = append(vcard.Extras, contentLine)
+	}
+}
+
+// called once the card is read to resolve data depending on several content lines
+func (vcard *VCard) complete(di *DirectoryInfoReader, labels map[string]string) {
+	vcard.resolveLabels(labels)
+	vcard.matchDeliveryLabels()
+	vcard.matchDefaultPostal()
+	if di.NameFromFN && len(vcard.FamilyNames) == 0 && len(vcard.GivenNames) == 0 {
+		vcard.GivenNames, vcard.FamilyNames = ParseName(vcard.FormattedName)
 	}
 }
 
 func (vcard *VCard) WriteTo(di *DirectoryInfoWriter) {
-	di.WriteContentLine(&ContentLine{"", "BEGIN", nil, StructuredValue{Value{"VCARD"}}})
-	di.WriteContentLine(&ContentLine{"", "VERSION", nil, StructuredValue{Value{"3.0"}}})
-	di.WriteContentLine(&ContentLine{"", "FN", nil, StructuredValue{Value{vcard.FormattedName}}})
-	di.WriteContentLine(&ContentLine{"", "N", nil, StructuredValue{vcard.FamilyNames, vcard.GivenNames, vcard.AdditionalNames, vcard.HonorificNames, vcard.HonorificSuffixes}})
-	if len(vcard.NickNames) != 0 {
-		di.WriteContentLine(&ContentLine{"", "NICKNAME", nil, StructuredValue{vcard.NickNames}})
+	di.cardVersion = vcard.Version
+	di.lowestPref = vcard.lowestPrefs()
+	di.sources, di.written = vcard.sources, nil
+	defer func() { di.cardVersion, di.lowestPref, di.sources, di.written = "", nil, nil, nil }()
+	if err := di.checkRequired(vcard); err != nil {
+		if di.err == nil {
+			di.err = err
+		}
+		return
 	}
-	vcard.Photo.WriteTo(di)
-	if len(vcard.Birthday) != 0 {
-		di.WriteContentLine(&ContentLine{"", "BDAY", nil, StructuredValue{Value{vcard.Birthday}}})
+	if di.SeparatorBlankLine && di.cards > 0 && di.collect == nil {
+		di.writeString("\r\n")
 	}
+	di.cards++
+	di.WriteContentLine(&ContentLine{"", "BEGIN", nil, StructuredValue{Value{"VCARD"}}, nil, nil})
+	di.WriteContentLine(&ContentLine{"", "VERSION", nil, StructuredValue{Value{di.version()}}, nil, nil})
+	// N is required in 3.0, in 4.0 an empty N is meaningless for non person cards
+	writeName := vcard.hasName() || di.version() != "4.0" || !vcard.isNonPerson() && !vcard.nameRedacted
+	// SORT-AS is a 4.0 parameter of N, or of FN for the cards without N
+	var sortAs map[string]Value
+	if len(vcard.SortAs) != 0 && di.version() == "4.0" {
+		sortAs = map[string]Value{"SORT-AS": {strings.Join(vcard.SortAs, ",")}}
+	}
+	if len(vcard.Alternates) != 0 && di.version() == "4.0" {
+		vcard.writeAltNames(di, writeName, sortAs)
+	} else if writeName {
+		di.WriteContentLine(&ContentLine{"", "FN", nil, StructuredValue{Value{di.formattedName(vcard)}}, nil, nil})
+		di.WriteContentLine(&ContentLine{"", "N", sortAs, vcard.nameValue(), nil, nil})
+	} else {
+		di.WriteContentLine(&ContentLine{"", "FN", sortAs, StructuredValue{Value{di.formattedName(vcard)}}, nil, nil})
+	}
+	if len(vcard.PhoneticGiven) != 0 {
+		di.WriteContentLine(&ContentLine{"", "X-PHONETIC-FIRST-NAME", nil, StructuredValue{Value{vcard.PhoneticGiven}}, nil, nil})
+	}
+	if len(vcard.PhoneticFamily) != 0 {
+		di.WriteContentLine(&ContentLine{"", "X-PHONETIC-LAST-NAME", nil, StructuredValue{Value{vcard.PhoneticFamily}}, nil, nil})
+	}
+	if len(vcard.NickNames) != 0 || vcard.HasProperty("NICKNAME") {
+		di.WriteContentLine(&ContentLine{"", "NICKNAME", nil, StructuredValue{vcard.NickNames}, nil, nil})
+	}
+	if (len(vcard.Photo.Data) != 0 || vcard.HasProperty("PHOTO")) && !vcard.Photo.Skipped {
+		vcard.Photo.write(di)
+	}
+	if len(vcard.Birthday) != 0 || vcard.HasProperty("BDAY") {
+		di.WriteContentLine(&ContentLine{"", "BDAY", dateParams(di, vcard.BirthdayScale, vcard.Birthday), StructuredValue{Value{vcard.Birthday}}, nil, nil})
+	}
+	if len(vcard.Anniversary) != 0 {
+		// ANNIVERSARY is defined by 4.0, X-ANNIVERSARY is used before
+		name := "X-ANNIVERSARY"
+		if di.version() == "4.0" {
+			name = "ANNIVERSARY"
+		}
+		di.WriteContentLine(&ContentLine{"", name, dateParams(di, vcard.AnniversaryScale, vcard.Anniversary), StructuredValue{Value{vcard.Anniversary}}, nil, nil})
+	}
+	var groups groupNumbering
 	for _, addr := range vcard.Addresses {
+		addr.Group = groups.property(addr.Group, "")
 		addr.WriteTo(di)
 	}
-	for _, tel := range vcard.Telephones {
+	vcard.writeDefaultPostal(di)
+	telephones, emails := vcard.Telephones, vcard.Emails
+	if di.SortByPreference {
+		telephones, emails = sortTelephones(telephones), sortEmails(emails)
+	}
+	for _, tel := range telephones {
+		tel.Group = groups.property(tel.Group, tel.Label)
 		tel.WriteTo(di)
 	}
-	for _, email := range vcard.Emails {
+	for _, email := range emails {
+		email.Group = groups.property(email.Group, email.Label)
 		email.WriteTo(di)
 	}
-	if len(vcard.Title) != 0 {
-		di.WriteContentLine(&ContentLine{"", "TITLE", nil, StructuredValue{Value{vcard.Title}}})
+	for _, title := range vcard.Titles {
+		di.WriteContentLine(&ContentLine{"", "TITLE", nil, StructuredValue{Value{title}}, nil, nil})
+	}
+	for _, role := range vcard.Roles {
+		di.WriteContentLine(&ContentLine{"", "ROLE", nil, StructuredValue{Value{role}}, nil, nil})
+	}
+	if len(vcard.Org) != 0 || vcard.HasProperty("ORG") {
+		di.WriteContentLine(&ContentLine{"", "ORG", nil, StructuredValue{vcard.Org}, nil, nil})
+	}
+	if len(vcard.Categories) != 0 || vcard.HasProperty("CATEGORIES") {
+		di.WriteContentLine(&ContentLine{"", "CATEGORIES", nil, StructuredValue{vcard.Categories}, nil, nil})
 	}
-	if len(vcard.Role) != 0 {
-		di.WriteContentLine(&ContentLine{"", "ROLE", nil, StructuredValue{Value{vcard.Role}}})
+	if len(vcard.Note) != 0 || vcard.HasProperty("NOTE") {
+		di.WriteContentLine(&ContentLine{"", "NOTE", nil, StructuredValue{Value{vcard.Note}}, nil, nil})
 	}
-	if len(vcard.Org) != 0 {
-		di.WriteContentLine(&ContentLine{"", "ORG", nil, StructuredValue{vcard.Org}})
+	for _, url := range vcard.URLs {
+		// Apple shows a home page through its label only
+		if di.appleLabels && url.Label == "" && url.Type.Has("homepage") {
+			url.Label = "HomePage"
+			url.Type.Remove("homepage")
+		}
+		url.Group = groups.property(url.Group, url.Label)
+		url.WriteTo(di)
 	}
-	if len(vcard.Categories) != 0 {
-		di.WriteContentLine(&ContentLine{"", "CATEGORIES", nil, StructuredValue{vcard.Categories}})
+	if vcard.XAddressBookServer {
+		if len(vcard.Kind) != 0 {
+			di.WriteContentLine(&ContentLine{"", "X-ADDRESSBOOKSERVER-KIND", nil, StructuredValue{Value{vcard.Kind}}, nil, nil})
+		}
+		for _, member := range vcard.Members {
+			di.WriteContentLine(&ContentLine{"", "X-ADDRESSBOOKSERVER-MEMBER", nil, StructuredValue{Value{member}}, nil, nil})
+		}
+	} else if di.version() == "4.0" {
+		if len(vcard.Kind) != 0 {
+			di.WriteContentLine(&ContentLine{"", "KIND", nil, StructuredValue{Value{vcard.Kind}}, nil, nil})
+		}
+		for _, member := range vcard.Members {
+			di.WriteContentLine(&ContentLine{"", "MEMBER", nil, StructuredValue{Value{member}}, nil, nil})
+		}
+	}
+	if vcard.Geo != nil {
+		vcard.Geo.WriteTo(di)
 	}
-	if len(vcard.Note) != 0 {
-		di.WriteContentLine(&ContentLine{"", "NOTE", nil, StructuredValue{Value{vcard.Note}}})
+	if len(vcard.TimeZone) != 0 || vcard.HasProperty("TZ") {
+		di.WriteContentLine(&ContentLine{"", "TZ", nil, StructuredValue{Value{vcard.TimeZone}}, nil, nil})
 	}
-	if len(vcard.URL) != 0 {
-		di.WriteContentLine(&ContentLine{"", "URL", nil, StructuredValue{Value{vcard.URL}}})
+	if len(vcard.UID) == 0 && di.GenerateUID {
+		if uid, err := newUUID(); err == nil {
+			vcard.UID = uid
+		} else if di.err == nil {
+			di.err = err
+		}
+	}
+	if len(vcard.UID) != 0 || vcard.HasProperty("UID") {
+		di.WriteContentLine(&ContentLine{"", "UID", nil, StructuredValue{Value{vcard.UID}}, nil, nil})
+	}
+	if di.StampRevision {
+		vcard.Revision = time.Now().UTC()
+	}
+	if !vcard.Revision.IsZero() {
+		writeRevision(di, vcard.Revision)
 	}
 	for _, jab := range vcard.XJabbers {
+		jab.Group = groups.property(jab.Group, jab.Label)
 		jab.WriteTo(di)
 	}
+	for _, impp := range vcard.IMPPs {
+		impp.Group = groups.property(impp.Group, impp.Label)
+		impp.WriteTo(di)
+	}
+	for _, date := range vcard.CustomDates {
+		date.Group = groups.property(date.Group, date.Label)
+		date.WriteTo(di)
+	}
+	writeInterests(di, "EXPERTISE", vcard.Expertises)
+	writeInterests(di, "HOBBY", vcard.Hobbies)
+	writeInterests(di, "INTEREST", vcard.Interests)
+	// unlike the interests, ORG-DIRECTORY and the RFC 6474 and 8605
+	// properties are kept before 4.0 as extension properties
+	for _, uri := range vcard.OrgDirectories {
+		di.WriteContentLine(&ContentLine{"", "ORG-DIRECTORY", nil, StructuredValue{Value{uri}}, nil, nil})
+	}
+	if len(vcard.Birthplace) != 0 {
+		if strings.EqualFold(vcard.BirthplaceValue, "uri") {
+			di.WriteContentLine(&ContentLine{"", "BIRTHPLACE", map[string]Value{"VALUE": {vcard.BirthplaceValue}}, uriValue(vcard.Birthplace), nil, nil})
+		} else {
+			di.WriteContentLine(&ContentLine{"", "BIRTHPLACE", nil, StructuredValue{Value{vcard.Birthplace}}, nil, nil})
+		}
+	}
+	if len(vcard.Deathplace) != 0 {
+		di.WriteContentLine(&ContentLine{"", "DEATHPLACE", nil, StructuredValue{Value{vcard.Deathplace}}, nil, nil})
+	}
+	if len(vcard.Deathdate) != 0 {
+		di.WriteContentLine(&ContentLine{"", "DEATHDATE", dateParams(di, vcard.DeathdateScale, vcard.Deathdate), StructuredValue{Value{vcard.Deathdate}}, nil, nil})
+	}
+	for _, uri := range vcard.ContactURIs {
+		di.WriteContentLine(&ContentLine{"", "CONTACT-URI", nil, StructuredValue{Value{uri}}, nil, nil})
+	}
+	if len(vcard.AgentURI) != 0 {
+		// AGENT is replaced by RELATED in 4.0
+		if di.version() == "4.0" {
+			di.WriteContentLine(&ContentLine{"", "RELATED", map[string]Value{"TYPE": {"agent"}}, StructuredValue{Value{vcard.AgentURI}}, nil, nil})
+		} else {
+			di.WriteContentLine(&ContentLine{"", "AGENT", map[string]Value{"VALUE": {"uri"}}, StructuredValue{Value{vcard.AgentURI}}, nil, nil})
+		}
+	}
 	if len(vcard.XABShowAs) != 0 {
-		di.WriteContentLine(&ContentLine{"", "X-ABShowAs", nil, StructuredValue{Value{vcard.XABShowAs}}})
+		di.WriteContentLine(&ContentLine{"", "X-ABShowAs", nil, StructuredValue{Value{vcard.XABShowAs}}, nil, nil})
 	}
 	if len(vcard.XABuid) != 0 {
-		di.WriteContentLine(&ContentLine{"", "X-ABUID", nil, StructuredValue{Value{vcard.XABuid}}})
+		di.WriteContentLine(&ContentLine{"", "X-ABUID", nil, StructuredValue{Value{vcard.XABuid}}, nil, nil})
+	}
+	// the extras are written with their own parameters
+	di.sources = nil
+	for _, extra := range vcard.Extras {
+		// BEGIN and VERSION must be the first lines and END the last one,
+		// strict parsers reject the card otherwise
+		switch strings.ToUpper(extra.Name) {
+		case "BEGIN", "VERSION", "END":
+			continue
+		}
+		// no data to write for a binary property skipped when read
+		if len(extra.Value) == 0 && vcard.skippedBinary(extra.Name) {
+			continue
+		}
+		if extra.Group != "" {
+			grouped := *extra
+			grouped.Group = groups.extra(extra.Group)
+			extra = &grouped
+		}
+		di.WriteContentLine(extra)
 	}
-	di.WriteContentLine(&ContentLine{"", "END", nil, StructuredValue{Value{"VCARD"}}})
+	di.WriteContentLine(&ContentLine{"", "END", nil, StructuredValue{Value{"VCARD"}}, nil, nil})
+}
+
+// return true if the data of the binary property name was skipped when read
+func (vcard *VCard) skippedBinary(name string) bool {
+	for _, skipped := range vcard.SkippedBinary {
+		if strings.EqualFold(skipped, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// serialize the vcard to w as Directory Information
+func (vcard *VCard) Write(w io.Writer) error {
+	return WriteAll(w, []*VCard{vcard})
+}
+
+// serialize each card to w, stop at the first write error
+func WriteAll(w io.Writer, cards []*VCard) error {
+	bw := bufio.NewWriter(w)
+	di := NewDirectoryInfoWriter(bw)
+	for _, vcard := range cards {
+		vcard.WriteTo(di)
+		if err := di.Err(); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// serialize the cards to w ordered by less, e.g: by FormattedName.
+// the order of cards is left unchanged, equal cards keep their order
+func WriteAllSorted(w io.Writer, cards []*VCard, less func(a, b *VCard) bool) error {
+	sorted := append([]*VCard(nil), cards...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return less(sorted[i], sorted[j])
+	})
+	return WriteAll(w, sorted)
 }
 
 func (photo *Photo) WriteTo(di *DirectoryInfoWriter) {
 	if len(photo.Data) == 0 {
 		return
 	}
+	photo.write(di)
+}
+
+// write the photo, even without data
+func (photo *Photo) write(di *DirectoryInfoWriter) {
+	photo = di.encodePhoto(di.externalize(photo))
+	// inline data is a data URI in 4.0, ENCODING is not defined anymore
+	if di.version() == "4.0" && photo.isBase64() && !photo.isURI() && photo.Data != "" {
+		di.WriteContentLine(&ContentLine{"", "PHOTO", nil, photo.dataURI(), nil, photo.Folds})
+		return
+	}
 	params := make(map[string]Value)
 	if photo.Encoding != "" {
 		params["ENCODING"] = Value{photo.Encoding}
 	}
 	if photo.Type != "" {
-		params["type"] = Value{photo.Type}
+		if di.version() == "4.0" {
+			params["MEDIATYPE"] = Value{mediaTypeFromToken(photo.Type)}
+		} else {
+			params["type"] = Value{tokenFromMediaType(photo.Type)}
+		}
 	}
 	if photo.Value != "" {
 		params["VALUE"] = Value{photo.Value}
 	}
-	if photo.Encoding == "" && photo.Type == "" && photo.Value == "" {
+	// BASE64 is not a 4.0 parameter, the value is a URI there
+	if photo.Encoding == "" && photo.Type == "" && photo.Value == "" && di.version() != "4.0" {
 		params["BASE64"] = Value{}
 	}
-	di.WriteContentLine(&ContentLine{"", "PHOTO", params, StructuredValue{Value{photo.Data}}})
+	di.WriteContentLine(&ContentLine{"", "PHOTO", params, StructuredValue{Value{photo.Data}}, nil, photo.Folds})
 }
 
+// the line breaks of the 4.0 LABEL parameter are written \n, a parameter
+// value having no escaping, e.g: ADR;LABEL="1 Main St\nSpringfield", or
+// percent-encoded with EncodePercent
+var (
+	labelParamEscaper   = strings.NewReplacer("\r\n", `\n`, "\n", `\n`)
+	labelParamUnescaper = strings.NewReplacer(`\n`, "\n", `\N`, "\n")
+)
+
 func (addr *Address) WriteTo(di *DirectoryInfoWriter) {
-	params := make(map[string]Value)
-	params["type"] = addr.Type
-	di.WriteContentLine(&ContentLine{"", "ADR", params, StructuredValue{Value{addr.PostOfficeBox}, Value{addr.ExtendedAddress}, Value{addr.Street}, Value{addr.Locality}, Value{addr.Region}, Value{addr.PostalCode}, Value{addr.CountryName}}})
+	params, order := typedParams(addr.source, addr.Type)
+	value := StructuredValue{Value{addr.PostOfficeBox}, Value{addr.ExtendedAddress}, Value{addr.Street}, Value{addr.Locality}, Value{addr.Region}, Value{addr.PostalCode}, Value{addr.CountryName}}
+	for _, extra := range addr.Extra {
+		value = append(value, Value{extra})
+	}
+	if addr.Geo != nil && di.version() == "4.0" {
+		params["GEO"] = Value{addr.Geo.URI()}
+	}
+	if addr.CountryCode != "" && di.version() == "4.0" {
+		params["CC"] = Value{addr.CountryCode}
+	}
+	// the delivery label is an ADR parameter in 4.0 and a LABEL property before
+	if addr.Label != "" && di.version() == "4.0" {
+		label := addr.Label
+		if !di.EncodePercent {
+			label = labelParamEscaper.Replace(label)
+		}
+		params["LABEL"] = Value{label}
+	}
+	di.WriteContentLine(&ContentLine{addr.Group, "ADR", params, value, order, nil})
+	if addr.Label != "" && di.version() != "4.0" {
+		params, _ := typedParams(nil, addr.Type)
+		di.WriteContentLine(&ContentLine{"", "LABEL", params, StructuredValue{Value{addr.Label}}, nil, nil})
+	}
+}
+
+func (date *CustomDate) WriteTo(di *DirectoryInfoWriter) {
+	var params map[string]Value
+	if len(date.Type) != 0 {
+		params, _ = typedParams(nil, date.Type)
+	}
+	di.WriteContentLine(&ContentLine{date.Group, "X-ABDATE", params, StructuredValue{Value{date.Date}}, nil, nil})
+	writeABLabel(di, date.Group, date.Label, date.labelWrapped)
 }
 
 func (tel *Telephone) WriteTo(di *DirectoryInfoWriter) {
-	params := make(map[string]Value)
-	params["type"] = tel.Type
-	di.WriteContentLine(&ContentLine{"", "TEL", params, StructuredValue{Value{tel.Number}}})
+	params, order := typedParams(tel.source, prefTypes(di, "TEL", tel.Type, tel.Pref))
+	if tel.Pref > 0 && di.version() == "4.0" {
+		params["PREF"] = Value{strconv.Itoa(tel.Pref)}
+	}
+	number := tel.Number
+	if tel.uri && di.version() == "4.0" {
+		params["VALUE"] = Value{"uri"}
+		number = "tel:" + number
+	}
+	di.WriteContentLine(&ContentLine{tel.Group, "TEL", params, StructuredValue{Value{number}}, order, nil})
+	writeABLabel(di, tel.Group, tel.Label, tel.labelWrapped)
 }
 
 func (email *Email) WriteTo(di *DirectoryInfoWriter) {
-	params := make(map[string]Value)
-	params["type"] = email.Type
-	di.WriteContentLine(&ContentLine{"", "EMAIL", params, StructuredValue{Value{email.Address}}})
+	params, order := typedParams(email.source, prefTypes(di, "EMAIL", email.Type, email.Pref))
+	if email.Pref > 0 && di.version() == "4.0" {
+		params["PREF"] = Value{strconv.Itoa(email.Pref)}
+	}
+	address := email.Address
+	if email.uri && di.version() == "4.0" {
+		params["VALUE"] = Value{"uri"}
+		address = "mailto:" + address
+	}
+	di.WriteContentLine(&ContentLine{email.Group, "EMAIL", params, StructuredValue{Value{address}}, order, nil})
+	writeABLabel(di, email.Group, email.Label, email.labelWrapped)
+}
+
+func (url *URL) WriteTo(di *DirectoryInfoWriter) {
+	var params map[string]Value
+	var order []string
+	if len(url.Type) != 0 || url.source != nil {
+		params, order = typedParams(url.source, url.Type)
+	}
+	di.WriteContentLine(&ContentLine{url.Group, "URL", params, StructuredValue{Value{url.Value}}, order, nil})
+	writeABLabel(di, url.Group, url.Label, url.labelWrapped)
+}
+
+// return the first title, empty if the vcard has no TITLE
+func (vcard *VCard) Title() string {
+	if len(vcard.Titles) > 0 {
+		return vcard.Titles[0]
+	}
+	return ""
+}
+
+// return the first role, empty if the vcard has no ROLE
+func (vcard *VCard) Role() string {
+	if len(vcard.Roles) > 0 {
+		return vcard.Roles[0]
+	}
+	return ""
+}
+
+// return the first URL, empty if the vcard has no URL
+func (vcard *VCard) URL() string {
+	if len(vcard.URLs) > 0 {
+		return vcard.URLs[0].Value
+	}
+	return ""
 }
 
 func (jab *XJabber) WriteTo(di *DirectoryInfoWriter) {
-	params := make(map[string]Value)
-	params["type"] = jab.Type
-	di.WriteContentLine(&ContentLine{"", "X-JABBER", params, StructuredValue{Value{jab.Address}}})
+	params, order := typedParams(jab.source, jab.Type)
+	di.WriteContentLine(&ContentLine{jab.Group, "X-JABBER", params, StructuredValue{Value{jab.Address}}, order, nil})
+	writeABLabel(di, jab.Group, jab.Label, jab.labelWrapped)
+}
+
+// remove the scheme, e.g: mailto:, from an URI value, return true if it was present
+func trimScheme(value, scheme string) (string, bool) {
+	if len(value) >= len(scheme) && strings.EqualFold(value[:len(scheme)], scheme) {
+		return value[len(scheme):], true
+	}
+	return value, false
+}
+
+// return the scheme of an URI, e.g: xmpp for xmpp:a@b.c
+func uriScheme(uri string) string {
+	if i := strings.Index(uri, ":"); i > 0 {
+		return strings.ToLower(uri[:i])
+	}
+	return ""
+}
+
+// X-SERVICE-TYPE is written if the service differs from the URI scheme
+func (impp *IMPP) WriteTo(di *DirectoryInfoWriter) {
+	var params map[string]Value
+	var order []string
+	if types := prefTypes(di, "IMPP", impp.Type, impp.Pref); len(types) != 0 || impp.source != nil {
+		params, order = typedParams(impp.source, types)
+	} else {
+		params = make(map[string]Value)
+	}
+	for key := range params {
+		if strings.EqualFold(key, "X-SERVICE-TYPE") {
+			delete(params, key)
+		}
+	}
+	if impp.Service != "" && !strings.EqualFold(impp.Service, uriScheme(impp.URI)) {
+		params["X-SERVICE-TYPE"] = Value{impp.Service}
+	}
+	if impp.Pref > 0 && di.version() == "4.0" {
+		params["PREF"] = Value{strconv.Itoa(impp.Pref)}
+	}
+	if len(params) == 0 {
+		params = nil
+	}
+	name := "IMPP"
+	if impp.outlook {
+		name = "X-MS-IMADDRESS"
+	}
+	di.WriteContentLine(&ContentLine{impp.Group, name, params, StructuredValue{Value{impp.URI}}, order, nil})
+	writeABLabel(di, impp.Group, impp.Label, impp.labelWrapped)
 }
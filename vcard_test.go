@@ -0,0 +1,111 @@
+package vcard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSkipBinaryDataWrite(t *testing.T) {
+	di := NewDirectoryInfoReader(strings.NewReader("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nPHOTO;ENCODING=b;TYPE=JPEG:AAAA\r\nLOGO;ENCODING=b;TYPE=PNG:BBBB\r\nLOGO;VALUE=uri:http://l\r\nKEY;ENCODING=b:CCCC\r\nEND:VCARD\r\n"))
+	di.SkipBinaryData = true
+	v := di.ReadVCard()
+	if !v.Photo.Skipped || strings.Join(v.SkippedBinary, ",") != "LOGO,KEY" || len(v.Extras) != 3 {
+		t.Fatalf("%+v", v)
+	}
+	var b bytes.Buffer
+	v.WriteTo(NewDirectoryInfoWriter(&b))
+	out := b.String()
+	if strings.Contains(out, "PHOTO") || strings.Contains(out, "KEY") || strings.Contains(out, "ENCODING") || !strings.Contains(out, "LOGO;VALUE=uri:http://l") {
+		t.Fatal(out)
+	}
+}
+
+func TestParameterPercentEncoding(t *testing.T) {
+	in := "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:a\r\nADR;LABEL=123%20Main%0AApt%202:;;123 Main;;;;\r\nEND:VCARD\r\n"
+	if label := readCard(t, in).Addresses[0].Label; label != "123%20Main%0AApt%202" {
+		t.Fatalf("%q", label)
+	}
+	di := NewDirectoryInfoReader(strings.NewReader(in))
+	di.DecodePercent = true
+	v := di.ReadVCard()
+	if label := v.Addresses[0].Label; label != "123 Main\nApt 2" {
+		t.Fatalf("%q", label)
+	}
+	v.Addresses[0].Label += " \"100%\""
+	var b bytes.Buffer
+	w := NewDirectoryInfoWriter(&b)
+	w.EncodePercent = true
+	v.WriteTo(w)
+	if out := b.String(); !strings.Contains(out, "LABEL=123 Main%0AApt 2 %22100%25%22;") {
+		t.Fatal(out)
+	}
+	di = NewDirectoryInfoReader(strings.NewReader(b.String()))
+	di.DecodePercent = true
+	if label := di.ReadVCard().Addresses[0].Label; label != v.Addresses[0].Label {
+		t.Fatalf("%q", label)
+	}
+}
+
+func TestLoggerWarnings(t *testing.T) {
+	var logger captureLogger
+	di := NewDirectoryInfoReader(strings.NewReader("FN:outside\r\nBEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nN:a;b;c;d;e;f\r\nGEO:north\r\nX-FOO:bar\r\nEND:VCARD\r\n"))
+	di.Logger = &logger
+	if v := di.ReadVCard(); v == nil || v.FormattedName != "a" {
+		t.Fatalf("%+v", v)
+	}
+	want := []string{"Not read , FN: [[outside]]\n", "N data has more fields: 6\n", "Invalid GEO: [[north]]\n", "Not read , X-FOO: [[bar]]\n"}
+	if strings.Join(logger.messages, "|") != strings.Join(want, "|") {
+		t.Fatalf("%q", logger.messages)
+	}
+	if stats := di.Stats(); stats.Warnings != len(want) || stats.Skipped != 2 {
+		t.Fatalf("%+v", stats)
+	}
+}
+
+func TestOnProperty(t *testing.T) {
+	di := NewDirectoryInfoReader(strings.NewReader("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nNOTE:secret\r\nTITLE:boss\r\nEND:VCARD\r\n"))
+	var names []string
+	di.OnProperty(func(cl *ContentLine, vcard *VCard) bool {
+		names = append(names, cl.Name)
+		return false
+	})
+	di.OnProperty(func(cl *ContentLine, vcard *VCard) bool {
+		if cl.Name != "NOTE" {
+			return false
+		}
+		vcard.Categories = append(vcard.Categories, cl.Value.GetText())
+		return true
+	})
+	di.OnProperty(func(cl *ContentLine, vcard *VCard) bool {
+		if cl.Name == "NOTE" {
+			t.Fatal("handled NOTE passed to the next handler")
+		}
+		return false
+	})
+	v := di.ReadVCard()
+	if v.Note != "" || len(v.Categories) != 1 || v.Categories[0] != "secret" || len(v.Titles) != 1 || v.Titles[0] != "boss" {
+		t.Fatalf("%+v", v)
+	}
+	if strings.Join(names, ",") != "VERSION,FN,NOTE,TITLE" {
+		t.Fatal(names)
+	}
+}
+
+func TestHandle(t *testing.T) {
+	di := NewDirectoryInfoReader(strings.NewReader("BEGIN:VCARD\r\nVERSION:3.0\r\nFN:a\r\nx-skype:john\r\nX-FOO:bar\r\nEND:VCARD\r\n"))
+	var skype string
+	di.Handle("X-SKYPE", func(cl *ContentLine, vcard *VCard) {
+		skype = cl.Value.GetText()
+	})
+	v := di.ReadVCard()
+	if skype != "john" {
+		t.Fatal(skype)
+	}
+	if len(v.Extras) != 1 || v.Extras[0].Name != "X-FOO" {
+		t.Fatalf("%+v", v.Extras)
+	}
+	if stats := di.Stats(); stats.Skipped != 1 {
+		t.Fatalf("%+v", stats)
+	}
+}